@@ -0,0 +1,181 @@
+package bbucket
+
+import "sort"
+
+// Aggregation computes values over the objects in a bucket, in a single read transaction per call.
+// Start one with Bucket.Aggregate and restrict it with Range, Prefix and Filter.
+// Extractor functions receive a pointer to a newly allocated object of the same type as dst.
+// Get your object of type T using: `*ptr.(*T)`
+type Aggregation struct {
+	br     Bucket
+	dst    interface{}
+	bounds bounds
+	filter func(key []byte, ptr interface{}) (bool, error)
+}
+
+// Aggregate returns an Aggregation over all objects in the bucket, decoded into values of the same type as dst.
+func (br Bucket) Aggregate(dst interface{}) *Aggregation {
+	return &Aggregation{br: br, dst: dst}
+}
+
+// Range restricts the aggregation to objects with from <= key < to.
+// A nil from or to leaves that side of the range open.
+func (a *Aggregation) Range(from, to []byte) *Aggregation {
+	a.bounds = bounds{from: from, to: to}
+	return a
+}
+
+// Prefix restricts the aggregation to objects whose key starts with p.
+func (a *Aggregation) Prefix(p []byte) *Aggregation {
+	a.bounds = prefixBounds(p)
+	return a
+}
+
+// Filter restricts the aggregation to objects for which f returns true.
+func (a *Aggregation) Filter(f func(key []byte, ptr interface{}) (bool, error)) *Aggregation {
+	a.filter = f
+	return a
+}
+
+// GroupBy counts the objects per group returned by f.
+func (a *Aggregation) GroupBy(f func(ptr interface{}) (group string, err error)) (map[string]int, error) {
+	if f == nil {
+		return nil, ErrNilFuncPassed
+	}
+
+	counts := map[string]int{}
+	err := a.each(func(ptr interface{}) error {
+		group, err := f(ptr)
+		if err != nil {
+			return err
+		}
+
+		counts[group]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// Distinct returns the distinct values returned by f, sorted.
+func (a *Aggregation) Distinct(f func(ptr interface{}) (string, error)) ([]string, error) {
+	if f == nil {
+		return nil, ErrNilFuncPassed
+	}
+
+	seen := map[string]bool{}
+	values := []string{}
+	err := a.each(func(ptr interface{}) error {
+		v, err := f(ptr)
+		if err != nil {
+			return err
+		}
+
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(values)
+	return values, nil
+}
+
+// Sum returns the sum of the numbers returned by f, or 0 if there are no objects.
+func (a *Aggregation) Sum(f func(ptr interface{}) (float64, error)) (float64, error) {
+	s, err := a.summarize(f)
+	return s.sum, err
+}
+
+// Min returns the lowest number returned by f.
+// If there are no objects, it returns ErrObjectNotFound
+func (a *Aggregation) Min(f func(ptr interface{}) (float64, error)) (float64, error) {
+	s, err := a.summarize(f)
+	if err == nil && s.n == 0 {
+		err = ErrObjectNotFound
+	}
+
+	return s.min, err
+}
+
+// Max returns the highest number returned by f.
+// If there are no objects, it returns ErrObjectNotFound
+func (a *Aggregation) Max(f func(ptr interface{}) (float64, error)) (float64, error) {
+	s, err := a.summarize(f)
+	if err == nil && s.n == 0 {
+		err = ErrObjectNotFound
+	}
+
+	return s.max, err
+}
+
+// Avg returns the mean of the numbers returned by f.
+// If there are no objects, it returns ErrObjectNotFound
+func (a *Aggregation) Avg(f func(ptr interface{}) (float64, error)) (float64, error) {
+	s, err := a.summarize(f)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.n == 0 {
+		return 0, ErrObjectNotFound
+	}
+
+	return s.sum / float64(s.n), nil
+}
+
+type summary struct {
+	n             int
+	sum, min, max float64
+}
+
+func (a *Aggregation) summarize(f func(ptr interface{}) (float64, error)) (summary, error) {
+	if f == nil {
+		return summary{}, ErrNilFuncPassed
+	}
+
+	var s summary
+	err := a.each(func(ptr interface{}) error {
+		v, err := f(ptr)
+		if err != nil {
+			return err
+		}
+
+		if s.n == 0 || v < s.min {
+			s.min = v
+		}
+		if s.n == 0 || v > s.max {
+			s.max = v
+		}
+		s.sum += v
+		s.n++
+
+		return nil
+	})
+
+	return s, err
+}
+
+// each passes every object within the bounds that passes the filter to f.
+func (a *Aggregation) each(f func(ptr interface{}) error) error {
+	return a.br.View(func(r Reader) error {
+		return r.scan("Aggregate", a.bounds, scanOptions{}, a.dst, func(k []byte, ptr interface{}) (bool, error) {
+			if a.filter != nil {
+				ok, err := a.filter(k, ptr)
+				if err != nil || !ok {
+					return false, err
+				}
+			}
+
+			return false, f(ptr)
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package bbucket
+
+import (
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	br := getTicketRepo()
+	defer br.Close()
+
+	age := func(ptr interface{}) (float64, error) {
+		return float64(ptr.(*ticket).Age), nil
+	}
+	status := func(ptr interface{}) (string, error) {
+		return ptr.(*ticket).Status, nil
+	}
+
+	t.Run(`group by`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		counts, err := br.Aggregate(&ticket{}).GroupBy(status)
+		assert.NoError(err)
+		assert.Cmp(map[string]int{`open`: 4, `closed`: 1}, counts)
+	})
+
+	t.Run(`distinct`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		values, err := br.Aggregate(&ticket{}).Distinct(status)
+		assert.NoError(err)
+		assert.Cmp([]string{`closed`, `open`}, values)
+	})
+
+	t.Run(`numbers`, func(t *testing.T) {
+		assert := assert.New(t)
+		a := br.Aggregate(&ticket{})
+
+		sum, err := a.Sum(age)
+		assert.NoError(err)
+		assert.Eq(156.0, sum)
+
+		min, err := a.Min(age)
+		assert.NoError(err)
+		assert.Eq(20.0, min)
+
+		max, err := a.Max(age)
+		assert.NoError(err)
+		assert.Eq(40.0, max)
+
+		avg, err := a.Avg(age)
+		assert.NoError(err)
+		assert.Eq(31.2, avg)
+	})
+
+	t.Run(`restricted`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		open := func(_ []byte, ptr interface{}) (bool, error) {
+			return ptr.(*ticket).Status == `open`, nil
+		}
+
+		sum, err := br.Aggregate(&ticket{}).Range([]byte(`2`), []byte(`5`)).Filter(open).Sum(age)
+		assert.NoError(err)
+		assert.Eq(71.0, sum)
+
+		max, err := br.Aggregate(&ticket{}).Prefix([]byte(`1`)).Max(age)
+		assert.NoError(err)
+		assert.Eq(20.0, max)
+	})
+
+	t.Run(`empty`, func(t *testing.T) {
+		assert := assert.New(t)
+		a := br.Aggregate(&ticket{}).Prefix([]byte(`9`))
+
+		sum, err := a.Sum(age)
+		assert.NoError(err)
+		assert.Eq(0.0, sum)
+
+		_, err = a.Min(age)
+		assert.Eq(ErrObjectNotFound, err)
+		_, err = a.Avg(age)
+		assert.Eq(ErrObjectNotFound, err)
+
+		counts, err := a.GroupBy(status)
+		assert.NoError(err)
+		assert.Eq(0, len(counts))
+	})
+
+	t.Run(`errors`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := br.Aggregate(&ticket{}).Sum(func(interface{}) (float64, error) {
+			return 0, ErrNoKey
+		})
+		assert.Eq(ErrNoKey, err)
+
+		_, err = br.Aggregate(&ticket{}).GroupBy(nil)
+		assert.Eq(ErrNilFuncPassed, err)
+	})
+}
@@ -0,0 +1,25 @@
+package bbucket
+
+import "go.etcd.io/bbolt"
+
+// Batched routes single object writes through DB.Batch, so that concurrent writes
+// share transactions and fsyncs. This applies to Create, Update, Delete, Patch, PatchOps,
+// Models().Create, Models().Save and Counter().Incr.
+// Writes only return once their batch is committed, so durability is unchanged.
+// DB.Batch may run a write more than once, so the functions passed to Update should not
+// have side effects other than modifying the object they receive.
+func Batched() Option {
+	return func(br *Bucket) {
+		br.batched = true
+	}
+}
+
+// write runs f in a write transaction, using BucketBatch if the Bucket is Batched.
+// f must be idempotent: when a batch fails, it is rolled back and f is run again.
+func (br Bucket) write(f func(*bbolt.Bucket) error) error {
+	if br.batched {
+		return br.BucketBatch(f)
+	}
+
+	return br.BucketUpdate(f)
+}
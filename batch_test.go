@@ -0,0 +1,137 @@
+package bbucket
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestBatched(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+	Batched()(&br)
+
+	t.Run(`concurrent creates of the same key`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var created, exists int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := br.Create([]byte(`same`), testStruct{ID: `same`, Data: i})
+				switch {
+				case err == nil:
+					atomic.AddInt32(&created, 1)
+				case errors.Is(err, ErrObjectAlreadyExists):
+					atomic.AddInt32(&exists, 1)
+				default:
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Eq(int32(1), created)
+		assert.Eq(int32(19), exists)
+	})
+
+	t.Run(`concurrent updates`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := br.Update(testStruct1.Key(), &testStruct{}, func(ptr interface{}) (interface{}, error) {
+					o := *ptr.(*testStruct)
+					o.Data++
+					return o, nil
+				})
+				assert.NoError(err)
+			}()
+		}
+		wg.Wait()
+
+		actual, err := getTestStruct(br, testStruct1.Key())
+		assert.NoError(err)
+		assert.Eq(testStruct1.Data+20, actual.Data)
+	})
+
+	t.Run(`generated keys are only set after commit`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		objects := make([]autoStruct, 20)
+		var wg sync.WaitGroup
+		for i := range objects {
+			wg.Add(1)
+			go func(obj *autoStruct) {
+				defer wg.Done()
+				assert.NoError(br.Models().Create(obj))
+			}(&objects[i])
+		}
+		wg.Wait()
+
+		seen := map[uint64]bool{}
+		for _, obj := range objects {
+			assert.Eq(false, seen[obj.ID])
+			seen[obj.ID] = true
+
+			var actual autoStruct
+			assert.NoError(br.Get(Itob(int(obj.ID)), &actual))
+			assert.Eq(obj, actual)
+		}
+	})
+
+	t.Run(`delete`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(br.Delete(testStruct2.Key()))
+		assert.Eq(true, errors.Is(br.Delete(testStruct2.Key()), ErrObjectNotFound))
+	})
+}
+
+func benchmarkCreate(b *testing.B, opts ...Option) {
+	path := `bench.db`
+	defer os.Remove(path)
+
+	db, err := bbolt.Open(path, 0666, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	br, err := Open(db, testBucket, opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// simulate many concurrent requests, as under HTTP load
+	var i int64
+	b.SetParallelism(256)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := br.Create(Itob(int(atomic.AddInt64(&i, 1))), testStruct1)
+			if err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCreate(b *testing.B) {
+	benchmarkCreate(b)
+}
+
+func BenchmarkCreateBatched(b *testing.B) {
+	benchmarkCreate(b, Batched())
+}
@@ -4,27 +4,59 @@ import "go.etcd.io/bbolt"
 
 // Bucket has wrappers around Tx and Bucket to reduce boilerplate for simple CRUD operations
 // It does not support sub-buckets
-// A new Bucket should always be created using the New() constructor to ensure the bucket exists.
+// A new Bucket should always be created using the Open() or New() constructor to ensure the bucket exists.
 type Bucket struct {
 	DB     *bbolt.DB
 	Bucket []byte
+
+	onDecodeError   func(err error)
+	strict          bool
+	collisionPolicy CollisionPolicy
+	merge           MergeFunc
+	batched         bool
+	cache           *cache
+	indexes         []Index
 }
 
-// New returns a bbucket struct and ensures the bucket exists
-// Panics for an invalid bucket name
-func New(db *bbolt.DB, bucket []byte) Bucket {
+// Option configures a Bucket. Pass options to Open or New.
+type Option func(*Bucket)
+
+// Open returns a bbucket struct and ensures the bucket exists
+// It returns an error for an invalid bucket name or if the bucket cannot be created.
+func Open(db *bbolt.DB, bucket []byte, opts ...Option) (Bucket, error) {
+	br := Bucket{
+		DB:     db,
+		Bucket: bucket,
+	}
+
+	for _, opt := range opts {
+		opt(&br)
+	}
+
 	err := db.Update(func(tx *bbolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists(bucket)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return br.createIndexes(tx)
 	})
 	if err != nil {
-		panic(err)
+		return Bucket{}, err
 	}
 
-	return Bucket{
-		DB:     db,
-		Bucket: bucket,
+	return br, nil
+}
+
+// New returns a bbucket struct and ensures the bucket exists
+// Panics for an invalid bucket name, use Open to handle the error instead.
+func New(db *bbolt.DB, bucket []byte, opts ...Option) Bucket {
+	br, err := Open(db, bucket, opts...)
+	if err != nil {
+		panic(err)
 	}
+
+	return br
 }
 
 // Close closes the underlying bbolt DB.
@@ -57,3 +89,40 @@ func (br Bucket) BucketUpdate(f func(*bbolt.Bucket) error) error {
 		return f(b)
 	})
 }
+
+// BucketBatch is used internally and allows for custom implementations.
+// It wraps DB.Batch() and Tx.Bucket()
+// f may be called multiple times, so it must be idempotent.
+func (br Bucket) BucketBatch(f func(*bbolt.Bucket) error) error {
+	return br.DB.Batch(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(br.Bucket)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+
+		return f(b)
+	})
+}
+
+// put stores data at key, updates the indexes and keeps the cache in sync once the transaction commits.
+// All writes to the bucket should go through put and del.
+func (br Bucket) put(b *bbolt.Bucket, key, data []byte) error {
+	err := br.updateIndexes(b, key, data)
+	if err != nil {
+		return err
+	}
+
+	br.invalidateOnCommit(b.Tx(), key)
+	return b.Put(key, data)
+}
+
+// del deletes key, updates the indexes and keeps the cache in sync once the transaction commits.
+func (br Bucket) del(b *bbolt.Bucket, key []byte) error {
+	err := br.updateIndexes(b, key, nil)
+	if err != nil {
+		return err
+	}
+
+	br.invalidateOnCommit(b.Tx(), key)
+	return b.Delete(key)
+}
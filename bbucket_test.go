@@ -1,6 +1,7 @@
 package bbucket
 
 import (
+	"bytes"
 	"encoding/json"
 	"testing"
 	"time"
@@ -63,6 +64,19 @@ func getTestRepo() Bucket {
 	_ = db.Update(func(tx *bbolt.Tx) error {
 		_ = tx.DeleteBucket(testBucket)
 		_, _ = tx.CreateBucket(testBucket)
+
+		// remove the index buckets of earlier tests
+		var indexes [][]byte
+		_ = tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+			if bytes.HasPrefix(name, []byte("test.index.")) {
+				indexes = append(indexes, append([]byte{}, name...))
+			}
+			return nil
+		})
+		for _, name := range indexes {
+			_ = tx.DeleteBucket(name)
+		}
+
 		return nil
 	})
 
@@ -122,3 +136,29 @@ func TestNew(t *testing.T) {
 	assert.Eq(expected.DB, actual.DB)
 	assert.Cmp(expected.Bucket, actual.Bucket)
 }
+
+func TestOpen(t *testing.T) {
+	assert := assert.New(t)
+
+	db, err := bbolt.Open(testPath, 0666, &bbolt.Options{Timeout: 1 * time.Second})
+	assert.NoError(err)
+
+	bucketName := []byte("myBucket")
+
+	var applied bool
+	br, err := Open(db, bucketName, func(br *Bucket) {
+		applied = true
+	})
+	assert.NoError(err)
+	assert.Eq(db, br.DB)
+	assert.Cmp(bucketName, br.Bucket)
+	assert.Eq(true, applied)
+
+	_, err = Open(db, nil)
+	assert.Eq(bbolt.ErrBucketNameRequired, err)
+
+	db.Close()
+
+	_, err = Open(db, bucketName)
+	assert.Eq(bbolt.ErrDatabaseNotOpen, err)
+}
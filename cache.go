@@ -0,0 +1,223 @@
+package bbucket
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// Cache keeps up to size decoded objects in memory, so Get doesn't decode hot keys again.
+// Get returns deep copies, so callers can't modify the cached objects.
+// With a cache, Get overwrites dst entirely instead of decoding into it.
+// Writes through the Bucket invalidate the keys they change once their transaction commits.
+// Writes made directly in BucketUpdate or BucketBatch, or through a Bucket opened
+// without the same cache, are not seen by the cache.
+func Cache(size int) Option {
+	return func(br *Bucket) {
+		if size > 0 {
+			br.cache = newCache(size)
+		}
+	}
+}
+
+// CacheStats reports the use of the cache of a Bucket.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Len    int
+}
+
+// CacheStats returns the hits, misses and number of cached objects.
+// It returns zero stats if the Bucket has no cache.
+func (br Bucket) CacheStats() CacheStats {
+	if br.cache == nil {
+		return CacheStats{}
+	}
+
+	return br.cache.stats()
+}
+
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+	version uint64 // incremented on every invalidation
+	hits    uint64
+	misses  uint64
+}
+
+type cacheEntry struct {
+	key   string
+	typ   reflect.Type
+	value reflect.Value
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// get copies the cached object into dst, or calls load with a fresh pointer and caches the result.
+func (c *cache) get(key []byte, dst interface{}, load func(ptr interface{}) error) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return load(dst) // let decoding report the invalid destination
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[string(key)]; ok && e.Value.(*cacheEntry).typ == rv.Type() {
+		c.order.MoveToFront(e)
+		c.hits++
+		value := e.Value.(*cacheEntry).value
+		c.mu.Unlock()
+
+		rv.Elem().Set(deepCopy(value))
+		return nil
+	}
+	c.misses++
+	version := c.version
+	c.mu.Unlock()
+
+	ptr := fresh(dst)
+	err := load(ptr)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(ptr).Elem()
+	rv.Elem().Set(deepCopy(value))
+	c.add(string(key), rv.Type(), value, version)
+	return nil
+}
+
+// add caches a value, unless a key was invalidated since version was read.
+// In that case the value may have been read before a write committed.
+func (c *cache) add(key string, typ reflect.Type, value reflect.Value, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version != version {
+		return
+	}
+
+	entry := &cacheEntry{key: key, typ: typ, value: value}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.entries, e.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+func (c *cache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{Hits: c.hits, Misses: c.misses, Len: c.order.Len()}
+}
+
+// invalidateOnCommit removes key from the cache once tx commits.
+func (br Bucket) invalidateOnCommit(tx *bbolt.Tx, key []byte) {
+	if br.cache == nil {
+		return
+	}
+
+	k := string(key)
+	tx.OnCommit(func() {
+		br.cache.remove(k)
+	})
+}
+
+// deepCopy returns a copy of v that shares no memory with it.
+// Unexported struct fields are copied shallowly, since JSON doesn't decode them.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		copyFields(c, v)
+		return c
+	}
+
+	return v
+}
+
+// copyFields deep copies the exported fields of v into c, which must be addressable.
+// This includes the exported fields of embedded structs of unexported types, since JSON decodes those too.
+func copyFields(c, v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		switch {
+		case c.Field(i).CanSet():
+			c.Field(i).Set(deepCopy(v.Field(i)))
+		case v.Type().Field(i).Anonymous && v.Field(i).Kind() == reflect.Struct:
+			copyFields(c.Field(i), v.Field(i))
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func getCachedTestRepo(size int) Bucket {
+	br := getTestRepo()
+	Cache(size)(&br)
+	return br
+}
+
+func getCached(br Bucket, key []byte) (testStruct, error) {
+	var t testStruct
+	err := br.Get(key, &t)
+	return t, err
+}
+
+func TestCache(t *testing.T) {
+	t.Run(`hits and misses`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		for i := 0; i < 3; i++ {
+			actual, err := getCached(br, testStruct1.Key())
+			assert.NoError(err)
+			assert.Eq(testStruct1, actual)
+		}
+
+		_, err := getCached(br, testStruct4.Key())
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
+
+		assert.Eq(CacheStats{Hits: 2, Misses: 2, Len: 1}, br.CacheStats())
+	})
+
+	t.Run(`deep copy`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		type nested struct {
+			Items []int
+			Tags  map[string]*int
+		}
+		one := 1
+		assert.NoError(br.Create([]byte(`nested`), nested{[]int{1, 2}, map[string]*int{`a`: &one}}))
+
+		var first nested
+		assert.NoError(br.Get([]byte(`nested`), &first))
+		first.Items[0] = 5
+		*first.Tags[`a`] = 5
+		first.Tags[`b`] = nil
+
+		var second nested
+		assert.NoError(br.Get([]byte(`nested`), &second))
+		assert.Cmp(nested{[]int{1, 2}, map[string]*int{`a`: &one}}, second)
+		assert.Eq(uint64(1), br.CacheStats().Hits)
+
+		// dst is overwritten, not decoded into
+		dirty := nested{Tags: map[string]*int{`c`: nil}}
+		assert.NoError(br.Get([]byte(`nested`), &dirty))
+		assert.Cmp(second, dirty)
+	})
+
+	t.Run(`deep copy of embedded unexported type`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		type innerTags struct {
+			Tags []string
+		}
+		type tagged struct {
+			innerTags
+			Name string
+		}
+		assert.NoError(br.Create([]byte(`tagged`), tagged{innerTags{[]string{`a`, `b`}}, `name`}))
+
+		var first tagged
+		assert.NoError(br.Get([]byte(`tagged`), &first))
+		first.Tags[0] = `changed`
+
+		var second tagged
+		assert.NoError(br.Get([]byte(`tagged`), &second))
+		assert.Cmp([]string{`a`, `b`}, second.Tags)
+		assert.Eq(`name`, second.Name)
+		assert.Eq(uint64(1), br.CacheStats().Hits)
+
+		second.Tags[1] = `changed`
+		var third tagged
+		assert.NoError(br.Get([]byte(`tagged`), &third))
+		assert.Cmp([]string{`a`, `b`}, third.Tags)
+	})
+
+	t.Run(`invalidation`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		get := func(key []byte) testStruct {
+			actual, _ := getCached(br, key)
+			return actual
+		}
+
+		get(testStruct1.Key())
+		assert.NoError(br.Update(testStruct1.Key(), &testStruct{}, func(ptr interface{}) (interface{}, error) {
+			return testStruct{`ABC`, 1}, nil
+		}))
+		assert.Eq(testStruct{`ABC`, 1}, get(testStruct1.Key()))
+
+		get(testStruct2.Key())
+		assert.NoError(br.Delete(testStruct2.Key()))
+		assert.Eq(testStruct{}, get(testStruct2.Key()))
+
+		get(testStruct3.Key())
+		assert.NoError(br.UpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+			obj := *ptr.(*testStruct)
+			obj.Data++
+			return obj.Key(), obj, nil
+		}))
+		assert.Eq(testStruct{`CDE`, 346}, get(testStruct3.Key()))
+
+		assert.NoError(br.Patch(testStruct3.Key(), []byte(`{"b":1}`)))
+		assert.Eq(testStruct{`CDE`, 1}, get(testStruct3.Key()))
+
+		assert.NoError(br.Delete(testStruct3.Key()))
+		assert.NoError(br.Create(testStruct3.Key(), testStruct3))
+		assert.Eq(testStruct3, get(testStruct3.Key()))
+	})
+
+	t.Run(`rollback keeps entry`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		_, err := getCached(br, testStruct1.Key())
+		assert.NoError(err)
+
+		err = br.CreateAll([]testStruct{{`ABC`, 1}, testStruct2}, func(obj interface{}) ([]byte, error) {
+			return obj.(testStruct).Key(), nil
+		})
+		assert.Error(err)
+		assert.Eq(1, br.CacheStats().Len)
+	})
+
+	t.Run(`eviction`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(2)
+		defer br.Close()
+
+		for _, key := range [][]byte{testStruct1.Key(), testStruct2.Key(), testStruct1.Key(), testStruct3.Key(), testStruct1.Key()} {
+			_, err := getCached(br, key)
+			assert.NoError(err)
+		}
+
+		// testStruct2 was least recently used when testStruct3 was added
+		assert.Eq(CacheStats{Hits: 2, Misses: 3, Len: 2}, br.CacheStats())
+	})
+
+	t.Run(`different type`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		_, err := getCached(br, testStruct1.Key())
+		assert.NoError(err)
+
+		var m map[string]interface{}
+		assert.NoError(br.Get(testStruct1.Key(), &m))
+		assert.Eq(`ABC`, m[`a`])
+		assert.Eq(uint64(0), br.CacheStats().Hits)
+	})
+
+	t.Run(`concurrent`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getCachedTestRepo(10)
+		defer br.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					_, _ = getCached(br, testStruct1.Key())
+					_ = br.Update(testStruct1.Key(), &testStruct{}, func(ptr interface{}) (interface{}, error) {
+						return testStruct{`ABC`, i*100 + j}, nil
+					})
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		var stored testStruct
+		assert.NoError(br.BucketView(func(b *bbolt.Bucket) error {
+			return json.Unmarshal(b.Get(testStruct1.Key()), &stored)
+		}))
+
+		actual, err := getCached(br, testStruct1.Key())
+		assert.NoError(err)
+		assert.Eq(stored, actual)
+	})
+}
@@ -0,0 +1,214 @@
+package bbucket
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// ChangeType describes a single Change in a ChangeSet.
+type ChangeType int
+
+const (
+	// ChangeInsert stores New at Key, which must not exist.
+	ChangeInsert ChangeType = iota
+	// ChangeUpdate replaces Old with New at Key.
+	ChangeUpdate
+	// ChangeMove deletes Key and stores New at NewKey, replacing Overwritten if it is not nil.
+	ChangeMove
+	// ChangeDelete deletes Key.
+	ChangeDelete
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeMove:
+		return "move"
+	case ChangeDelete:
+		return "delete"
+	}
+
+	return "unknown"
+}
+
+// Change is a single change to a bucket, with the stored values before and after.
+type Change struct {
+	Type        ChangeType
+	Key         []byte
+	NewKey      []byte
+	Old         []byte
+	New         []byte
+	Overwritten []byte
+}
+
+// ChangeSet lists the changes a bulk operation would make, without making them.
+// Use Bucket.Apply to commit it.
+type ChangeSet struct {
+	Bucket  []byte
+	Changes []Change
+}
+
+// DryRunCreateAll returns the changes CreateAll would make, using a read transaction.
+func (br Bucket) DryRunCreateAll(objs interface{}, keyFunc func(obj interface{}) (key []byte, err error)) (*ChangeSet, error) {
+	cs := &ChangeSet{Bucket: br.Bucket}
+	return cs, br.BucketView(func(b *bbolt.Bucket) error {
+		puts, err := br.collectCreates(b, "DryRunCreateAll", objs, keyFunc)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range puts {
+			cs.Changes = append(cs.Changes, Change{Type: ChangeInsert, Key: clone(item.key), New: item.data})
+		}
+
+		return nil
+	})
+}
+
+// DryRunUpdateAll returns the changes UpdateAll would make, using a read transaction.
+// f is called the same way as by UpdateAll, but nothing is stored.
+func (br Bucket) DryRunUpdateAll(dst interface{}, f func(ptr interface{}) (key []byte, object interface{}, err error)) (*ChangeSet, error) {
+	cs := &ChangeSet{Bucket: br.Bucket}
+	return cs, br.BucketView(func(b *bbolt.Bucket) error {
+		if f == nil {
+			return ErrNilFuncPassed
+		}
+
+		p := newPendingUpdates()
+		err := b.ForEach(func(originalKey, originalValue []byte) error {
+			return br.collectUpdate(p, "DryRunUpdateAll", originalKey, originalValue, dst, f)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = br.resolveCollisions(b, "DryRunUpdateAll", dst, p)
+		if err != nil {
+			return err
+		}
+
+		cs.Changes = p.changes(b)
+		return nil
+	})
+}
+
+// DryRunDeleteAll returns the changes DeleteAll would make, using a read transaction.
+func (br Bucket) DryRunDeleteAll(dst interface{}, f func(key []byte, ptr interface{}) (del bool, err error)) (*ChangeSet, error) {
+	cs := &ChangeSet{Bucket: br.Bucket}
+	return cs, br.BucketView(func(b *bbolt.Bucket) error {
+		keys, err := br.collectDeletes(b, "DryRunDeleteAll", dst, f)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			cs.Changes = append(cs.Changes, Change{Type: ChangeDelete, Key: clone(key), Old: clone(b.Get(key))})
+		}
+
+		return nil
+	})
+}
+
+// Apply commits a ChangeSet in a single transaction.
+// If any affected key no longer holds the value it held when the ChangeSet was made,
+// nothing is changed and ErrChangeSetConflict is returned.
+func (br Bucket) Apply(cs *ChangeSet) error {
+	if !bytes.Equal(cs.Bucket, br.Bucket) {
+		return ErrChangeSetBucket
+	}
+
+	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+		for _, c := range cs.Changes {
+			if !sameValue(b.Get(c.Key), c.Old) {
+				return br.keyError("Apply", c.Key, ErrChangeSetConflict)
+			}
+
+			if c.Type == ChangeMove && !sameValue(b.Get(c.NewKey), c.Overwritten) {
+				return br.keyError("Apply", c.NewKey, ErrChangeSetConflict)
+			}
+		}
+
+		for _, c := range cs.Changes {
+			if c.Type == ChangeMove || c.Type == ChangeDelete {
+				err := br.del(b, c.Key)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, c := range cs.Changes {
+			var err error
+			switch c.Type {
+			case ChangeInsert, ChangeUpdate:
+				err = br.put(b, c.Key, c.New)
+			case ChangeMove:
+				err = br.put(b, c.NewKey, c.New)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// changes lists the pending updates as a ChangeSet would.
+// Values are copied, since they are only valid during the transaction.
+func (p *pendingUpdates) changes(b *bbolt.Bucket) []Change {
+	var changes []Change
+	moved := map[string]bool{}
+	for _, item := range p.toBePut {
+		if item.dropped {
+			continue
+		}
+
+		if bytes.Equal(item.from, item.key) {
+			changes = append(changes, Change{
+				Type: ChangeUpdate,
+				Key:  clone(item.key),
+				Old:  clone(b.Get(item.key)),
+				New:  item.data,
+			})
+			continue
+		}
+
+		moved[string(item.from)] = true
+		changes = append(changes, Change{
+			Type:        ChangeMove,
+			Key:         clone(item.from),
+			NewKey:      clone(item.key),
+			Old:         clone(b.Get(item.from)),
+			New:         item.data,
+			Overwritten: clone(b.Get(item.key)),
+		})
+	}
+
+	for _, key := range p.toBeDeleted {
+		if p.kept[string(key)] || moved[string(key)] {
+			continue
+		}
+
+		changes = append(changes, Change{Type: ChangeDelete, Key: clone(key), Old: clone(b.Get(key))})
+	}
+
+	return changes
+}
+
+// sameValue reports whether two stored values are equal, telling missing (nil) and empty values apart.
+func sameValue(a, b []byte) bool {
+	return (a == nil) == (b == nil) && bytes.Equal(a, b)
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
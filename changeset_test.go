@@ -0,0 +1,115 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+func mustMarshal(obj interface{}) []byte {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func TestDryRunUpdateAll(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	updated := testStruct{`BCD`, 1}
+	cs, err := br.DryRunUpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+		o := *ptr.(*testStruct)
+		switch o.ID {
+		case `ABC`:
+			return []byte(`ZZZ`), o, nil
+		case `BCD`:
+			return o.Key(), updated, nil
+		}
+		return nil, nil, nil
+	})
+	assert.NoError(err)
+	assertUnchanged(assert, br)
+
+	assert.Cmp(&ChangeSet{
+		Bucket: testBucket,
+		Changes: []Change{
+			{Type: ChangeMove, Key: []byte(`ABC`), NewKey: []byte(`ZZZ`), Old: mustMarshal(testStruct1), New: mustMarshal(testStruct1)},
+			{Type: ChangeUpdate, Key: []byte(`BCD`), Old: mustMarshal(testStruct2), New: mustMarshal(updated)},
+			{Type: ChangeDelete, Key: []byte(`CDE`), Old: mustMarshal(testStruct3)},
+		},
+	}, cs)
+	assert.Eq(`move`, cs.Changes[0].Type.String())
+
+	err = br.Apply(cs)
+	assert.NoError(err)
+	assert.Cmp(map[string]testStruct{
+		`BCD`: updated,
+		`ZZZ`: testStruct1,
+	}, getAllByKey(br))
+
+	err = br.Apply(cs)
+	assert.Eq(true, errors.Is(err, ErrChangeSetConflict))
+}
+
+func TestDryRunDeleteAll(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	cs, err := br.DryRunDeleteAll(&testStruct{}, func(key []byte, ptr interface{}) (bool, error) {
+		return string(key) == `ABC`, nil
+	})
+	assert.NoError(err)
+	assertUnchanged(assert, br)
+	assert.Cmp([]Change{{Type: ChangeDelete, Key: []byte(`ABC`), Old: mustMarshal(testStruct1)}}, cs.Changes)
+
+	err = br.Apply(cs)
+	assert.NoError(err)
+	assert.Cmp([]testStruct{testStruct2, testStruct3}, getAllTestStructs(br))
+}
+
+func TestDryRunCreateAll(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	keyFunc := func(obj interface{}) ([]byte, error) {
+		return obj.(testStruct).Key(), nil
+	}
+
+	_, err := br.DryRunCreateAll([]testStruct{testStruct4, testStruct4}, keyFunc)
+	assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
+
+	cs, err := br.DryRunCreateAll([]testStruct{testStruct4}, keyFunc)
+	assert.NoError(err)
+	assertUnchanged(assert, br)
+	assert.Cmp([]Change{{Type: ChangeInsert, Key: testStruct4.Key(), New: mustMarshal(testStruct4)}}, cs.Changes)
+
+	// conflicting insert
+	assert.NoError(br.Create(testStruct4.Key(), testStruct5))
+	err = br.Apply(cs)
+	assert.Eq(true, errors.Is(err, ErrChangeSetConflict))
+
+	assert.NoError(br.Delete(testStruct4.Key()))
+	err = br.Apply(cs)
+	assert.NoError(err)
+
+	actual, err := getTestStruct(br, testStruct4.Key())
+	assert.NoError(err)
+	assert.Eq(testStruct4, actual)
+}
+
+func TestApplyOtherBucket(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	err := br.Apply(&ChangeSet{Bucket: []byte(`other`)})
+	assert.Eq(ErrChangeSetBucket, err)
+}
@@ -0,0 +1,196 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// CollisionPolicy decides what UpdateAll does when objects are moved to a key that is already taken,
+// either by an existing object or by another moved object.
+type CollisionPolicy int
+
+const (
+	// CollisionFail aborts with a CollisionError. This is the default.
+	CollisionFail CollisionPolicy = iota
+	// CollisionKeepExisting keeps the object that was already at the key.
+	// Objects that could not be moved stay at their original key, unchanged.
+	// If several objects are moved to a free key, the first one in key order wins.
+	CollisionKeepExisting
+	// CollisionOverwrite stores the last object moved to the key and discards the others.
+	CollisionOverwrite
+	// CollisionMerge combines the colliding objects using the MergeFunc passed to MergeOnCollision.
+	CollisionMerge
+)
+
+// MergeFunc combines two objects that UpdateAll wants to store at the same key.
+// existing and incoming are pointers to newly allocated objects of the same type as dst.
+// It should return the object to be stored, not a pointer.
+type MergeFunc func(key []byte, existing, incoming interface{}) (object interface{}, err error)
+
+// OnCollision sets the CollisionPolicy used by UpdateAll and UpdateAllChunked.
+func OnCollision(policy CollisionPolicy) Option {
+	return func(br *Bucket) {
+		br.collisionPolicy = policy
+	}
+}
+
+// MergeOnCollision makes UpdateAll and UpdateAllChunked merge colliding objects using f.
+func MergeOnCollision(f MergeFunc) Option {
+	return func(br *Bucket) {
+		br.collisionPolicy = CollisionMerge
+		br.merge = f
+	}
+}
+
+// CollisionError is returned when objects are moved to a key that is already taken.
+// It matches ErrObjectAlreadyExists when using errors.Is
+type CollisionError struct {
+	Op     string
+	Bucket []byte
+	Key    []byte
+	// Keys holds the original keys of all objects that claimed Key
+	Keys [][]byte
+}
+
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("%s %s[%q]: %v: claimed by %q", e.Op, e.Bucket, e.Key, ErrObjectAlreadyExists, e.Keys)
+}
+
+func (e *CollisionError) Unwrap() error {
+	return ErrObjectAlreadyExists
+}
+
+// resolveCollisions applies the CollisionPolicy to the pending updates.
+func (br Bucket) resolveCollisions(b *bbolt.Bucket, op string, dst interface{}, p *pendingUpdates) error {
+	freed := map[string]bool{}
+	for _, key := range p.toBeDeleted {
+		freed[string(key)] = true
+	}
+
+	stays := map[string]int{}    // key -> index of object edited in place
+	claims := map[string][]int{} // key -> indexes of objects moved to key
+	var targets []string
+	for i, put := range p.toBePut {
+		key := string(put.key)
+		if bytes.Equal(put.from, put.key) {
+			stays[key] = i
+			continue
+		}
+
+		if _, ok := claims[key]; !ok {
+			targets = append(targets, key)
+		}
+		claims[key] = append(claims[key], i)
+	}
+
+	for len(targets) > 0 {
+		key := targets[0]
+		targets = targets[1:]
+
+		movers := claims[key]
+		existing := !freed[key] && b.Get([]byte(key)) != nil
+		if len(movers) == 0 || !existing && len(movers) == 1 {
+			continue
+		}
+
+		switch br.collisionPolicy {
+		case CollisionOverwrite:
+			last := movers[len(movers)-1]
+			for _, i := range movers[:len(movers)-1] {
+				p.toBePut[i].dropped = true
+			}
+			if i, ok := stays[key]; ok {
+				p.toBePut[i].dropped = true
+			}
+			claims[key] = []int{last}
+
+		case CollisionKeepExisting:
+			winners := []int{}
+			if !existing {
+				winners = movers[:1]
+			}
+
+			for _, i := range movers[len(winners):] {
+				p.toBePut[i].dropped = true
+
+				from := string(p.toBePut[i].from)
+				freed[from] = false
+				p.kept[from] = true
+				if len(claims[from]) > 0 {
+					targets = append(targets, from)
+				}
+			}
+			claims[key] = winners
+
+		case CollisionMerge:
+			err := br.mergeCollision(b, key, existing, stays, movers, dst, p)
+			if err != nil {
+				return err
+			}
+			claims[key] = movers[:1]
+
+		default:
+			e := &CollisionError{Op: op, Bucket: br.Bucket, Key: []byte(key)}
+			if existing {
+				e.Keys = append(e.Keys, []byte(key))
+			}
+			for _, i := range movers {
+				e.Keys = append(e.Keys, append([]byte{}, p.toBePut[i].from...))
+			}
+			return e
+		}
+	}
+
+	return nil
+}
+
+// mergeCollision folds all objects claiming key into the first mover, using the MergeFunc.
+func (br Bucket) mergeCollision(b *bbolt.Bucket, key string, existing bool, stays map[string]int, movers []int, dst interface{}, p *pendingUpdates) error {
+	if br.merge == nil {
+		return ErrNilFuncPassed
+	}
+
+	var acc []byte
+	if i, ok := stays[key]; ok {
+		acc = p.toBePut[i].data
+		p.toBePut[i].dropped = true
+	} else if existing {
+		acc = b.Get([]byte(key))
+	}
+
+	for _, i := range movers {
+		incoming := p.toBePut[i].data
+		p.toBePut[i].dropped = true
+		if acc == nil {
+			acc = incoming
+			continue
+		}
+
+		existingPtr, incomingPtr := fresh(dst), fresh(dst)
+		err := br.decode(acc, existingPtr)
+		if err != nil {
+			return br.keyError("Merge", []byte(key), err)
+		}
+		err = br.decode(incoming, incomingPtr)
+		if err != nil {
+			return br.keyError("Merge", []byte(key), err)
+		}
+
+		object, err := br.merge([]byte(key), existingPtr, incomingPtr)
+		if err != nil {
+			return err
+		}
+
+		acc, err = json.Marshal(object)
+		if err != nil {
+			return br.keyError("Merge", []byte(key), err)
+		}
+	}
+
+	p.toBePut[movers[0]].data = acc
+	p.toBePut[movers[0]].dropped = false
+	return nil
+}
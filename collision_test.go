@@ -0,0 +1,198 @@
+package bbucket
+
+import (
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+// moveTo returns an UpdateAll function that moves objects according to moves.
+func moveTo(moves map[string]string) func(ptr interface{}) ([]byte, interface{}, error) {
+	return func(ptr interface{}) ([]byte, interface{}, error) {
+		o := *ptr.(*testStruct)
+		if key, ok := moves[o.ID]; ok {
+			return []byte(key), o, nil
+		}
+		return o.Key(), o, nil
+	}
+}
+
+func getAllByKey(br Bucket) map[string]testStruct {
+	out := map[string]testStruct{}
+	it := br.All(&testStruct{})
+	it.Seq(func(key []byte, ptr interface{}) bool {
+		out[string(key)] = *ptr.(*testStruct)
+		return true
+	})
+	if it.Err() != nil {
+		panic(it.Err())
+	}
+
+	return out
+}
+
+func TestCollisionFail(t *testing.T) {
+	t.Run(`existing key`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `BCD`}))
+		assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
+
+		var collision *CollisionError
+		assert.Eq(true, errors.As(err, &collision))
+		assert.Cmp([]byte(`BCD`), collision.Key)
+		assert.Cmp([][]byte{[]byte(`BCD`), []byte(`ABC`)}, collision.Keys)
+		assert.Eq(`UpdateAll test["BCD"]: object already exists: claimed by ["BCD" "ABC"]`, err.Error())
+		assertUnchanged(assert, br)
+	})
+
+	t.Run(`two objects moved to the same key`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `ZZZ`, `BCD`: `ZZZ`}))
+
+		var collision *CollisionError
+		assert.Eq(true, errors.As(err, &collision))
+		assert.Cmp([][]byte{[]byte(`ABC`), []byte(`BCD`)}, collision.Keys)
+		assertUnchanged(assert, br)
+	})
+
+	t.Run(`swapping keys is allowed`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `BCD`, `BCD`: `ABC`}))
+		assert.NoError(err)
+		assert.Cmp(map[string]testStruct{
+			`ABC`: testStruct2,
+			`BCD`: testStruct1,
+			`CDE`: testStruct3,
+		}, getAllByKey(br))
+	})
+
+	t.Run(`chunked`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.UpdateAllChunked(&testStruct{}, 1, moveTo(map[string]string{`ABC`: `CDE`}), nil)
+		assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
+		assertUnchanged(assert, br)
+	})
+}
+
+func TestCollisionKeepExisting(t *testing.T) {
+	t.Run(`existing key`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+		OnCollision(CollisionKeepExisting)(&br)
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `BCD`}))
+		assert.NoError(err)
+		assertUnchanged(assert, br)
+	})
+
+	t.Run(`first object wins`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+		OnCollision(CollisionKeepExisting)(&br)
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `ZZZ`, `BCD`: `ZZZ`}))
+		assert.NoError(err)
+		assert.Cmp(map[string]testStruct{
+			`BCD`: testStruct2,
+			`CDE`: testStruct3,
+			`ZZZ`: testStruct1,
+		}, getAllByKey(br))
+	})
+
+	t.Run(`kept objects block moves to their key`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+		OnCollision(CollisionKeepExisting)(&br)
+
+		assert.NoError(br.Create([]byte(`AAA`), testStruct4))
+		assert.NoError(br.Create([]byte(`ZZZ`), testStruct5))
+
+		err := br.UpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*testStruct)
+			switch o.ID {
+			case testStruct4.ID:
+				return []byte(`ABC`), o, nil
+			case `ABC`:
+				return []byte(`ZZZ`), o, nil
+			case testStruct5.ID:
+				return []byte(`ZZZ`), o, nil
+			}
+			return o.Key(), o, nil
+		})
+		assert.NoError(err)
+		assert.Cmp(map[string]testStruct{
+			`AAA`: testStruct4,
+			`ABC`: testStruct1,
+			`BCD`: testStruct2,
+			`CDE`: testStruct3,
+			`ZZZ`: testStruct5,
+		}, getAllByKey(br))
+	})
+}
+
+func TestCollisionOverwrite(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+	OnCollision(CollisionOverwrite)(&br)
+
+	err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `CDE`, `BCD`: `CDE`}))
+	assert.NoError(err)
+	assert.Cmp(map[string]testStruct{
+		`CDE`: testStruct2,
+	}, getAllByKey(br))
+}
+
+func TestCollisionMerge(t *testing.T) {
+	t.Run(`merge`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		var merged [][]byte
+		MergeOnCollision(func(key []byte, existing, incoming interface{}) (interface{}, error) {
+			merged = append(merged, key)
+			e, i := *existing.(*testStruct), *incoming.(*testStruct)
+			e.Data += i.Data
+			return e, nil
+		})(&br)
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `CDE`, `BCD`: `CDE`}))
+		assert.NoError(err)
+		assert.Cmp(map[string]testStruct{
+			`CDE`: {`CDE`, 123 + 234 + 345},
+		}, getAllByKey(br))
+		assert.Cmp([][]byte{[]byte(`CDE`), []byte(`CDE`)}, merged)
+	})
+
+	t.Run(`merge error`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		myErr := errors.New(`custom error`)
+		MergeOnCollision(func(key []byte, existing, incoming interface{}) (interface{}, error) {
+			return nil, myErr
+		})(&br)
+
+		err := br.UpdateAll(&testStruct{}, moveTo(map[string]string{`ABC`: `CDE`}))
+		assert.Eq(myErr, err)
+		assertUnchanged(assert, br)
+	})
+}
@@ -0,0 +1,137 @@
+package bbucket
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"go.etcd.io/bbolt"
+)
+
+// Counter stores int64 counters in a bucket as 8 byte big endian values, like Itob.
+// Use a separate bucket for counters, GetAll and friends cannot decode them.
+type Counter struct {
+	br     Bucket
+	shards int
+}
+
+// Counter returns a Counter that stores each counter under its own key.
+func (br Bucket) Counter() Counter {
+	return Counter{br: br}
+}
+
+// ShardedCounter returns a Counter that spreads each counter over n keys.
+// Incr updates a random shard using DB.Batch, so that concurrent increments of the
+// same counter are coalesced into fewer transactions. Get sums all shards.
+// Always use the same n for the same bucket.
+func (br Bucket) ShardedCounter(n int) Counter {
+	return Counter{br: br, shards: n}
+}
+
+// Incr adds delta to a counter and returns the new value.
+// Counters that do not exist start at 0.
+// If the key holds something other than a counter, it returns ErrInvalidCounter
+func (c Counter) Incr(key []byte, delta int64) (int64, error) {
+	var total int64
+	if c.shards <= 1 {
+		err := c.br.write(func(b *bbolt.Bucket) error {
+			var err error
+			total, err = c.incr(b, key, key, delta)
+			return err
+		})
+		return total, err
+	}
+
+	shard := c.shardKey(key, rand.Intn(c.shards))
+	err := c.br.BucketBatch(func(b *bbolt.Bucket) error {
+		_, err := c.incr(b, key, shard, delta)
+		if err != nil {
+			return err
+		}
+
+		total, err = c.sum(b, key)
+		return err
+	})
+
+	return total, err
+}
+
+// Get returns the value of a counter. Counters that do not exist are 0.
+func (c Counter) Get(key []byte) (int64, error) {
+	var total int64
+	err := c.br.BucketView(func(b *bbolt.Bucket) error {
+		var err error
+		total, err = c.sum(b, key)
+		return err
+	})
+
+	return total, err
+}
+
+// Reset deletes a counter, so that it is 0 again.
+func (c Counter) Reset(key []byte) error {
+	return c.br.BucketUpdate(func(b *bbolt.Bucket) error {
+		if c.shards <= 1 {
+			return c.br.del(b, key)
+		}
+
+		for i := 0; i < c.shards; i++ {
+			err := c.br.del(b, c.shardKey(key, i))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c Counter) incr(b *bbolt.Bucket, key, storageKey []byte, delta int64) (int64, error) {
+	v, err := c.value(b, key, storageKey)
+	if err != nil {
+		return 0, err
+	}
+
+	v += delta
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(v))
+	return v, c.br.put(b, storageKey, data)
+}
+
+func (c Counter) sum(b *bbolt.Bucket, key []byte) (int64, error) {
+	if c.shards <= 1 {
+		return c.value(b, key, key)
+	}
+
+	var total int64
+	for i := 0; i < c.shards; i++ {
+		v, err := c.value(b, key, c.shardKey(key, i))
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+
+	return total, nil
+}
+
+func (c Counter) value(b *bbolt.Bucket, key, storageKey []byte) (int64, error) {
+	data := b.Get(storageKey)
+	if data == nil {
+		return 0, nil
+	}
+
+	if len(data) != 8 {
+		return 0, c.br.keyError("Counter", key, ErrInvalidCounter)
+	}
+
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// shardKey returns the key of shard i of a counter: the key, 0xff and i as 2 byte big endian.
+func (c Counter) shardKey(key []byte, i int) []byte {
+	k := make([]byte, len(key)+3)
+	copy(k, key)
+	k[len(key)] = 0xff
+	binary.BigEndian.PutUint16(k[len(key)+1:], uint16(i))
+	return k
+}
@@ -0,0 +1,92 @@
+package bbucket
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+func TestCounter(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+	c := br.Counter()
+	key := []byte(`hits`)
+
+	t.Run(`incr`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := c.Get(key)
+		assert.NoError(err)
+		assert.Eq(int64(0), v)
+
+		v, err = c.Incr(key, 5)
+		assert.NoError(err)
+		assert.Eq(int64(5), v)
+
+		v, err = c.Incr(key, -7)
+		assert.NoError(err)
+		assert.Eq(int64(-2), v)
+
+		v, err = c.Get(key)
+		assert.NoError(err)
+		assert.Eq(int64(-2), v)
+		assert.Eq(getRaw(br, `hits`), string(Itob(-2)))
+	})
+
+	t.Run(`reset`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := c.Reset(key)
+		assert.NoError(err)
+
+		v, err := c.Get(key)
+		assert.NoError(err)
+		assert.Eq(int64(0), v)
+	})
+
+	t.Run(`not a counter`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := c.Incr(testStruct1.Key(), 1)
+		assert.Eq(true, errors.Is(err, ErrInvalidCounter))
+
+		_, err = c.Get(testStruct1.Key())
+		assert.Eq(true, errors.Is(err, ErrInvalidCounter))
+	})
+}
+
+func TestShardedCounter(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+	c := br.ShardedCounter(4)
+	key := []byte(`hits`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Incr(key, 2)
+			assert.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	v, err := c.Get(key)
+	assert.NoError(err)
+	assert.Eq(int64(100), v)
+
+	v, err = c.Incr(key, 1)
+	assert.NoError(err)
+	assert.Eq(int64(101), v)
+
+	err = c.Reset(key)
+	assert.NoError(err)
+
+	v, err = c.Get(key)
+	assert.NoError(err)
+	assert.Eq(int64(0), v)
+}
@@ -7,34 +7,20 @@ import (
 	"go.etcd.io/bbolt"
 )
 
-func (br Bucket) NextSequence() int {
-	var i uint64
-	err := br.BucketUpdate(func(b *bbolt.Bucket) error {
-		j, err := b.NextSequence()
-		i = j
-		return err
-	})
-	if err != nil {
-		panic(err)
-	}
-
-	return int(i)
-}
-
 // Create stores a new object in the bucket.
 // If the key already exists, it returns ErrObjectAlreadyExists
 func (br Bucket) Create(key []byte, obj interface{}) error {
-	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+	return br.write(func(b *bbolt.Bucket) error {
 		if b.Get(key) != nil {
-			return ErrObjectAlreadyExists
+			return br.keyError("Create", key, ErrObjectAlreadyExists)
 		}
 
 		data, err := json.Marshal(obj)
 		if err != nil {
-			return err
+			return br.keyError("Create", key, err)
 		}
 
-		return b.Put(key, data)
+		return br.put(b, key, data)
 	})
 }
 
@@ -46,38 +32,51 @@ func (br Bucket) Create(key []byte, obj interface{}) error {
 // When relying on NextSequence for indexing, call it inside keyFunc
 func (br Bucket) CreateAll(objs interface{}, keyFunc func(obj interface{}) (key []byte, err error)) error {
 	return br.BucketUpdate(func(b *bbolt.Bucket) error {
-		s := reflect.ValueOf(objs)
-		if s.Kind() != reflect.Slice {
-			return ErrNonSliceArgument
-		}
-
-		if s.IsNil() {
-			return nil
+		puts, err := br.collectCreates(b, "CreateAll", objs, keyFunc)
+		if err != nil {
+			return err
 		}
 
-		for i := 0; i < s.Len(); i++ {
-			obj := s.Index(i).Interface()
-
-			key, err := keyFunc(obj)
+		for _, item := range puts {
+			err := br.put(b, item.key, item.data)
 			if err != nil {
 				return err
 			}
+		}
 
-			if b.Get(key) != nil {
-				return ErrObjectAlreadyExists
-			}
+		return nil
+	})
+}
 
-			data, err := json.Marshal(obj)
-			if err != nil {
-				return err
-			}
+// collectCreates encodes the objects for CreateAll and checks that none of the keys are taken.
+func (br Bucket) collectCreates(b *bbolt.Bucket, op string, objs interface{}, keyFunc func(obj interface{}) (key []byte, err error)) ([]pendingPut, error) {
+	s := reflect.ValueOf(objs)
+	if s.Kind() != reflect.Slice {
+		return nil, ErrNonSliceArgument
+	}
 
-			err = b.Put(key, data)
-			if err != nil {
-				return err
-			}
+	var puts []pendingPut
+	taken := map[string]bool{}
+	for i := 0; i < s.Len(); i++ {
+		obj := s.Index(i).Interface()
+
+		key, err := keyFunc(obj)
+		if err != nil {
+			return nil, err
 		}
 
-		return nil
-	})
+		if taken[string(key)] || b.Get(key) != nil {
+			return nil, br.keyError(op, key, ErrObjectAlreadyExists)
+		}
+		taken[string(key)] = true
+
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, br.keyError(op, key, err)
+		}
+
+		puts = append(puts, pendingPut{key: key, data: data})
+	}
+
+	return puts, nil
 }
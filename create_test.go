@@ -2,6 +2,7 @@ package bbucket
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"git.fuyu.moe/Fuyu/assert"
@@ -64,7 +65,7 @@ func TestCreate(t *testing.T) {
 		assert.NoError(err)
 
 		err = br.Create(expected.Key(), expected)
-		assert.Eq(ErrObjectAlreadyExists, err)
+		assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
 
 		actual, err := getTestStruct(br, expected.Key())
 		assert.NoError(err)
@@ -73,18 +74,6 @@ func TestCreate(t *testing.T) {
 	})
 }
 
-func TestNextSequence(t *testing.T) {
-	assert := assert.New(t)
-	br := getTestRepo()
-	defer br.Close()
-
-	i := br.NextSequence()
-	assert.Eq(1, i)
-
-	i = br.NextSequence()
-	assert.Eq(2, i)
-}
-
 func TestCreateAll(t *testing.T) {
 	br := getTestRepo()
 	defer br.Close()
@@ -0,0 +1,102 @@
+package bbucket
+
+import "reflect"
+
+// ScanOption changes how GetAll, Find, GetMany and the scans of a Reader walk the bucket.
+type ScanOption func(*scanOptions)
+
+type scanOptions struct {
+	reverse  bool
+	sortKeys bool
+}
+
+func newScanOptions(opts []ScanOption) scanOptions {
+	var so scanOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+
+	return so
+}
+
+// Reverse walks the bucket in descending key order.
+func Reverse() ScanOption {
+	return func(so *scanOptions) {
+		so.reverse = true
+	}
+}
+
+// SortKeys makes GetMany look up the keys in key order instead of the given order.
+func SortKeys() ScanOption {
+	return func(so *scanOptions) {
+		so.sortKeys = true
+	}
+}
+
+// First decodes the object with the lowest key into dst and returns its key.
+// If the bucket is empty, it returns ErrObjectNotFound
+func (br Bucket) First(dst interface{}) (key []byte, err error) {
+	err = br.View(func(r Reader) error {
+		key, err = r.First(dst)
+		return err
+	})
+
+	return key, err
+}
+
+// Last decodes the object with the highest key into dst and returns its key.
+// With Itob keys, this is the newest object.
+// If the bucket is empty, it returns ErrObjectNotFound
+func (br Bucket) Last(dst interface{}) (key []byte, err error) {
+	err = br.View(func(r Reader) error {
+		key, err = r.Last(dst)
+		return err
+	})
+
+	return key, err
+}
+
+// Seek decodes the first object with a key at or after key into dst and returns its key.
+// If there is no such object, it returns ErrObjectNotFound
+func (br Bucket) Seek(key []byte, dst interface{}) (found []byte, err error) {
+	err = br.View(func(r Reader) error {
+		found, err = r.Seek(key, dst)
+		return err
+	})
+
+	return found, err
+}
+
+// First works like Bucket.First within the transaction.
+func (r Reader) First(dst interface{}) ([]byte, error) {
+	return r.one("First", bounds{}, scanOptions{}, dst)
+}
+
+// Last works like Bucket.Last within the transaction.
+func (r Reader) Last(dst interface{}) ([]byte, error) {
+	return r.one("Last", bounds{}, scanOptions{reverse: true}, dst)
+}
+
+// Seek works like Bucket.Seek within the transaction.
+func (r Reader) Seek(key []byte, dst interface{}) ([]byte, error) {
+	return r.one("Seek", bounds{from: key}, scanOptions{}, dst)
+}
+
+// one decodes the first object scan finds into dst and returns a copy of its key.
+func (r Reader) one(op string, bd bounds, so scanOptions, dst interface{}) ([]byte, error) {
+	var key []byte
+	err := r.scan(op, bd, so, dst, func(k []byte, ptr interface{}) (bool, error) {
+		key = append([]byte{}, k...)
+		reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(ptr).Elem())
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if key == nil {
+		return nil, ErrObjectNotFound
+	}
+
+	return key, nil
+}
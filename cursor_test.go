@@ -0,0 +1,91 @@
+package bbucket
+
+import (
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestFirstLastSeek(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	var obj testStruct
+	key, err := br.First(&obj)
+	assert.NoError(err)
+	assert.Eq(`ABC`, string(key))
+	assert.Eq(testStruct1, obj)
+
+	key, err = br.Last(&obj)
+	assert.NoError(err)
+	assert.Eq(`CDE`, string(key))
+	assert.Eq(testStruct3, obj)
+
+	key, err = br.Seek([]byte(`B`), &obj)
+	assert.NoError(err)
+	assert.Eq(`BCD`, string(key))
+	assert.Eq(testStruct2, obj)
+
+	key, err = br.Seek([]byte(`BCD`), &obj)
+	assert.NoError(err)
+	assert.Eq(`BCD`, string(key))
+
+	_, err = br.Seek([]byte(`D`), &obj)
+	assert.Eq(ErrObjectNotFound, err)
+
+	// corrupt objects are skipped with OnDecodeError
+	OnDecodeError(func(error) {})(&br)
+	assert.NoError(putCorrupt(br, `ZZZ`))
+	key, err = br.Last(&obj)
+	assert.NoError(err)
+	assert.Eq(`CDE`, string(key))
+
+	assert.NoError(br.DeleteAll(&testStruct{}, func([]byte, interface{}) (bool, error) {
+		return true, nil
+	}))
+	assert.NoError(br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.Delete([]byte(`ZZZ`))
+	}))
+
+	_, err = br.First(&obj)
+	assert.Eq(ErrObjectNotFound, err)
+	_, err = br.Last(&obj)
+	assert.Eq(ErrObjectNotFound, err)
+}
+
+func TestReverse(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	var all []testStruct
+	assert.NoError(br.GetAll(&testStruct{}, func(ptr interface{}) error {
+		all = append(all, *ptr.(*testStruct))
+		return nil
+	}, Reverse()))
+	assert.Cmp([]testStruct{testStruct3, testStruct2, testStruct1}, all)
+
+	var found testStruct
+	assert.NoError(br.Find(&found, func(_ []byte, ptr interface{}) (bool, error) {
+		return ptr.(*testStruct).Data < 300, nil
+	}, Reverse()))
+	assert.Eq(testStruct2, found)
+
+	err := br.View(func(r Reader) error {
+		var keys []string
+		collectKeys := func(key []byte, _ interface{}) error {
+			keys = append(keys, string(key))
+			return nil
+		}
+
+		assert.NoError(r.Range([]byte(`B`), []byte(`CDE`), &testStruct{}, collectKeys, Reverse()))
+		assert.NoError(r.Range([]byte(`AAA`), nil, &testStruct{}, collectKeys, Reverse()))
+		assert.NoError(r.Range(nil, []byte(`ZZZ`), &testStruct{}, collectKeys, Reverse()))
+		assert.NoError(r.Prefix([]byte(`A`), &testStruct{}, collectKeys, Reverse()))
+		assert.Cmp([]string{`BCD`, `CDE`, `BCD`, `ABC`, `CDE`, `BCD`, `ABC`, `ABC`}, keys)
+		return nil
+	})
+	assert.NoError(err)
+}
@@ -5,12 +5,58 @@ import "go.etcd.io/bbolt"
 // Delete deletes an object by key.
 // If the key doesn't exist, it return ErrObjectNotFound
 func (br Bucket) Delete(key []byte) error {
-	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+	return br.write(func(b *bbolt.Bucket) error {
 		data := b.Get(key)
 		if data == nil {
-			return ErrObjectNotFound
+			return br.keyError("Delete", key, ErrObjectNotFound)
+		}
+
+		return br.del(b, key)
+	})
+}
+
+// DeleteAll iterates over all objects in the bucket and deletes those for which f returns true.
+// It runs in a single transaction and deletes nothing if an error occurs.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// Get your object of type T using: `*ptr.(*T)`
+func (br Bucket) DeleteAll(dst interface{}, f func(key []byte, ptr interface{}) (del bool, err error)) error {
+	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+		keys, err := br.collectDeletes(b, "DeleteAll", dst, f)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			err := br.del(b, key)
+			if err != nil {
+				return err
+			}
 		}
 
-		return b.Delete(key)
+		return nil
 	})
 }
+
+// collectDeletes returns the keys of the objects for which f returns true.
+func (br Bucket) collectDeletes(b *bbolt.Bucket, op string, dst interface{}, f func(key []byte, ptr interface{}) (del bool, err error)) ([][]byte, error) {
+	if f == nil {
+		return nil, ErrNilFuncPassed
+	}
+
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		ptr, err := br.decodeEach(op, k, v, dst)
+		if err != nil || ptr == nil {
+			return err
+		}
+
+		del, err := f(k, ptr)
+		if del {
+			keys = append(keys, k)
+		}
+
+		return err
+	})
+
+	return keys, err
+}
@@ -1,6 +1,7 @@
 package bbucket
 
 import (
+	"errors"
 	"testing"
 
 	"git.fuyu.moe/Fuyu/assert"
@@ -32,6 +33,45 @@ func TestDelete(t *testing.T) {
 		assert.Eq(ErrObjectNotFound, err)
 
 		err = br.Delete(expected.Key())
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
+	})
+}
+
+func TestDeleteAll(t *testing.T) {
+	t.Run(`delete matching`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.DeleteAll(&testStruct{}, func(key []byte, ptr interface{}) (bool, error) {
+			return ptr.(*testStruct).Data > 200, nil
+		})
+		assert.NoError(err)
+		assert.Cmp([]testStruct{testStruct1}, getAllTestStructs(br))
+	})
+
+	t.Run(`delete nothing on error`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		myErr := errors.New(`custom error`)
+		err := br.DeleteAll(&testStruct{}, func(key []byte, ptr interface{}) (bool, error) {
+			if string(key) == `CDE` {
+				return false, myErr
+			}
+			return true, nil
+		})
+		assert.Eq(myErr, err)
+		assertUnchanged(assert, br)
+	})
+
+	t.Run(`nil function`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.DeleteAll(&testStruct{}, nil)
+		assert.Eq(ErrNilFuncPassed, err)
 	})
 }
@@ -2,6 +2,7 @@ package bbucket
 
 import (
 	"errors"
+	"fmt"
 
 	"go.etcd.io/bbolt"
 )
@@ -13,4 +14,44 @@ var (
 	ErrBucketNotFound      = bbolt.ErrBucketNotFound
 	ErrNilFuncPassed       = errors.New("nil function passed")
 	ErrNonSliceArgument    = errors.New("non-slice argument passed")
+	ErrNoKey               = errors.New("object has no key")
+	ErrNonPositiveCount    = errors.New("count must be positive")
+	ErrChangeSetConflict   = errors.New("object changed since change set was made")
+	ErrChangeSetBucket     = errors.New("change set belongs to another bucket")
+	ErrInvalidPatch        = errors.New("invalid patch")
+	ErrPatchTestFailed     = errors.New("patch test failed")
+	ErrInvalidCounter      = errors.New("value is not a counter")
+	ErrWriteBehindClosed   = errors.New("write-behind queue is closed")
+	ErrOtherDB             = errors.New("bucket belongs to another DB")
+	ErrInvalidQuery        = errors.New("invalid query")
+	ErrNoIndex             = errors.New("bucket has no such index")
+	ErrInvalidLocation     = errors.New("invalid location")
 )
+
+// KeyError records an error along with the operation, bucket and key that caused it.
+// Use errors.Is to check for the underlying error, e.g. errors.Is(err, ErrObjectNotFound)
+type KeyError struct {
+	Op     string
+	Bucket []byte
+	Key    []byte
+	Err    error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s %s[%q]: %v", e.Op, e.Bucket, e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// keyError wraps err in a KeyError.
+// The key is copied, since keys passed by bbolt are only valid during the transaction.
+func (br Bucket) keyError(op string, key []byte, err error) error {
+	return &KeyError{
+		Op:     op,
+		Bucket: br.Bucket,
+		Key:    append([]byte{}, key...),
+		Err:    err,
+	}
+}
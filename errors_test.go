@@ -0,0 +1,59 @@
+package bbucket
+
+import (
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestKeyError(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`not found`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Get([]byte(`blablabla`), &testStruct{})
+
+		var keyErr *KeyError
+		assert.Eq(true, errors.As(err, &keyErr))
+		assert.Eq(`Get`, keyErr.Op)
+		assert.Cmp(testBucket, keyErr.Bucket)
+		assert.Cmp([]byte(`blablabla`), keyErr.Key)
+		assert.Eq(ErrObjectNotFound, keyErr.Err)
+		assert.Eq(`Get test["blablabla"]: object not found`, err.Error())
+	})
+
+	t.Run(`decode error identifies key`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+			return b.Put([]byte(`BBB`), []byte(`{`))
+		})
+		assert.NoError(err)
+		defer func() {
+			_ = br.Delete([]byte(`BBB`))
+		}()
+
+		var keyErr *KeyError
+
+		err = br.GetAll(&testStruct{}, func(ptr interface{}) error { return nil })
+		assert.Eq(true, errors.As(err, &keyErr))
+		assert.Eq(`GetAll`, keyErr.Op)
+		assert.Cmp([]byte(`BBB`), keyErr.Key)
+
+		err = br.Find(&testStruct{}, func(key []byte, ptr interface{}) (bool, error) { return false, nil })
+		assert.Eq(true, errors.As(err, &keyErr))
+		assert.Eq(`Find`, keyErr.Op)
+		assert.Cmp([]byte(`BBB`), keyErr.Key)
+
+		err = br.UpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+			return nil, nil, nil
+		})
+		assert.Eq(true, errors.As(err, &keyErr))
+		assert.Eq(`UpdateAll`, keyErr.Op)
+		assert.Cmp([]byte(`BBB`), keyErr.Key)
+	})
+}
@@ -0,0 +1,356 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+// GeoIndex indexes objects by location for Near and Within.
+// f receives a pointer to a newly allocated object of the same type as dst and returns its coordinates in degrees.
+// Objects for which f returns ok = false, or invalid coordinates, are not indexed.
+// Get your object of type T using: `*ptr.(*T)`
+func GeoIndex(name string, dst interface{}, f func(ptr interface{}) (lat, lon float64, ok bool)) Index {
+	return &geoIndex{name: name, dst: dst, f: f}
+}
+
+type geoIndex struct {
+	name string
+	dst  interface{}
+	f    func(ptr interface{}) (lat, lon float64, ok bool)
+}
+
+// BBox is a bounding box in degrees. If MinLon > MaxLon, the box crosses the antimeridian.
+type BBox struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// geohashPrecision is the length of the geohashes stored in a geoIndex, about 4 cm.
+const geohashPrecision = 12
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371008.8
+
+func (gi *geoIndex) Name() string {
+	return gi.name
+}
+
+func (gi *geoIndex) Update(b *bbolt.Bucket, key, old, data []byte) error {
+	if lat, lon, ok := gi.location(old); ok {
+		err := b.Delete(indexEntry([]byte(geohash(lat, lon, geohashPrecision)), key))
+		if err != nil {
+			return err
+		}
+	}
+
+	if lat, lon, ok := gi.location(data); ok {
+		value := make([]byte, 16)
+		binary.BigEndian.PutUint64(value, math.Float64bits(lat))
+		binary.BigEndian.PutUint64(value[8:], math.Float64bits(lon))
+		return b.Put(indexEntry([]byte(geohash(lat, lon, geohashPrecision)), key), value)
+	}
+
+	return nil
+}
+
+// location returns the coordinates of a stored object.
+// Objects that can't be decoded are not indexed.
+func (gi *geoIndex) location(data []byte) (lat, lon float64, ok bool) {
+	if data == nil {
+		return 0, 0, false
+	}
+
+	ptr := fresh(gi.dst)
+	if json.Unmarshal(data, ptr) != nil {
+		return 0, 0, false
+	}
+
+	lat, lon, ok = gi.f(ptr)
+	if !ok || !validLocation(lat, lon) {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+// validLocation reports whether lat and lon are finite coordinates within range.
+// NaN fails both comparisons, and infinities are out of range.
+func validLocation(lat, lon float64) bool {
+	return math.Abs(lat) <= 90 && math.Abs(lon) <= 180
+}
+
+// Near calls f for every object within radius meters of (lat, lon), nearest first,
+// using the first GeoIndex of the Bucket. f also receives the distance in meters.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// If the location is invalid or radius is negative, NaN or infinite, it returns ErrInvalidLocation
+// If the Bucket has no GeoIndex, it returns ErrNoIndex
+// Get your object of type T using: `*ptr.(*T)`
+func (br Bucket) Near(lat, lon, radius float64, dst interface{}, f func(key []byte, ptr interface{}, distance float64) error) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	if !validLocation(lat, lon) || math.IsNaN(radius) || math.IsInf(radius, 0) || radius < 0 {
+		return ErrInvalidLocation
+	}
+
+	dLat := radius / earthRadius * 180 / math.Pi
+	box := BBox{MinLat: math.Max(lat-dLat, -90), MaxLat: math.Min(lat+dLat, 90), MinLon: -180, MaxLon: 180}
+	if cos := math.Cos((math.Abs(lat) + dLat) * math.Pi / 180); box.MaxLat < 90 && box.MinLat > -90 && cos > 0 {
+		dLon := dLat / cos
+		if dLon < 180 {
+			box.MinLon = normalizeLon(lon - dLon)
+			box.MaxLon = normalizeLon(lon + dLon)
+		}
+	}
+
+	return br.geoQuery(box, dst, func(pointLat, pointLon float64) (float64, bool) {
+		d := distance(lat, lon, pointLat, pointLon)
+		return d, d <= radius
+	}, f)
+}
+
+// Within calls f for every object inside box, in key order, using the first GeoIndex of the Bucket.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// If a corner of box is invalid or MinLat > MaxLat, it returns ErrInvalidLocation
+// If the Bucket has no GeoIndex, it returns ErrNoIndex
+// Get your object of type T using: `*ptr.(*T)`
+func (br Bucket) Within(box BBox, dst interface{}, f func(key []byte, ptr interface{}) error) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	if !validLocation(box.MinLat, box.MinLon) || !validLocation(box.MaxLat, box.MaxLon) || box.MinLat > box.MaxLat {
+		return ErrInvalidLocation
+	}
+
+	return br.geoQuery(box, dst, func(lat, lon float64) (float64, bool) {
+		return 0, box.contains(lat, lon)
+	}, func(key []byte, ptr interface{}, _ float64) error {
+		return f(key, ptr)
+	})
+}
+
+type geoResult struct {
+	key      []byte
+	distance float64
+}
+
+// geoQuery scans the geohash cells covering box, keeps the points for which match returns true
+// and passes their objects to f, ordered by the returned distance and then by key.
+func (br Bucket) geoQuery(box BBox, dst interface{}, match func(lat, lon float64) (float64, bool), f func(key []byte, ptr interface{}, distance float64) error) error {
+	var idx Index
+	for _, i := range br.indexes {
+		if _, ok := i.(*geoIndex); ok {
+			idx = i
+			break
+		}
+	}
+	if idx == nil {
+		return ErrNoIndex
+	}
+
+	return br.View(func(r Reader) error {
+		ib, err := r.indexBucket(idx)
+		if err != nil {
+			return err
+		}
+
+		b, err := r.bucket()
+		if err != nil {
+			return err
+		}
+
+		var results []geoResult
+		c := ib.Cursor()
+		for _, cell := range box.cover() {
+			prefix := []byte(cell)
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				if len(v) != 16 {
+					continue
+				}
+
+				lat := math.Float64frombits(binary.BigEndian.Uint64(v))
+				lon := math.Float64frombits(binary.BigEndian.Uint64(v[8:]))
+				if d, ok := match(lat, lon); ok {
+					results = append(results, geoResult{key: clone(indexEntryKey(k)), distance: d})
+				}
+			}
+		}
+
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].distance != results[j].distance {
+				return results[i].distance < results[j].distance
+			}
+			return bytes.Compare(results[i].key, results[j].key) < 0
+		})
+
+		for _, res := range results {
+			data := b.Get(res.key)
+			if data == nil {
+				continue
+			}
+
+			ptr, err := br.decodeEach("Geo", res.key, data, dst)
+			if err != nil {
+				return err
+			}
+			if ptr == nil {
+				continue
+			}
+
+			err = f(res.key, ptr, res.distance)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (box BBox) contains(lat, lon float64) bool {
+	if lat < box.MinLat || lat > box.MaxLat {
+		return false
+	}
+
+	if box.MinLon > box.MaxLon {
+		return lon >= box.MinLon || lon <= box.MaxLon
+	}
+
+	return lon >= box.MinLon && lon <= box.MaxLon
+}
+
+// maxCoverCells limits the number of geohash cells scanned for a box.
+const maxCoverCells = 64
+
+// cover returns the geohashes of the cells that together cover the box,
+// using the longest geohashes for which there are at most maxCoverCells cells.
+// Single character geohashes split the world into 32 cells, so the cover never exceeds maxCoverCells.
+// The box must be valid, see validLocation.
+func (box BBox) cover() []string {
+	boxes := []BBox{box}
+	if box.MinLon > box.MaxLon {
+		boxes = []BBox{
+			{MinLat: box.MinLat, MinLon: box.MinLon, MaxLat: box.MaxLat, MaxLon: 180},
+			{MinLat: box.MinLat, MinLon: -180, MaxLat: box.MaxLat, MaxLon: box.MaxLon},
+		}
+	}
+
+	for precision := geohashPrecision; precision > 1; precision-- {
+		width, height := geohashCellSize(precision)
+		n := 0
+		for _, b := range boxes {
+			n += (int((b.MaxLon-b.MinLon)/width) + 2) * (int((b.MaxLat-b.MinLat)/height) + 2)
+		}
+
+		if n <= maxCoverCells {
+			return coverCells(boxes, precision)
+		}
+	}
+
+	return coverCells(boxes, 1)
+}
+
+func coverCells(boxes []BBox, precision int) []string {
+	width, height := geohashCellSize(precision)
+	seen := map[string]bool{}
+	var cells []string
+	for _, b := range boxes {
+		for lat := b.MinLat; ; lat += height {
+			lat = math.Min(lat, b.MaxLat)
+			for lon := b.MinLon; ; lon += width {
+				lon = math.Min(lon, b.MaxLon)
+				cell := geohash(lat, lon, precision)
+				if !seen[cell] {
+					seen[cell] = true
+					cells = append(cells, cell)
+				}
+				if lon >= b.MaxLon {
+					break
+				}
+			}
+			if lat >= b.MaxLat {
+				break
+			}
+		}
+	}
+
+	sort.Strings(cells)
+	return cells
+}
+
+// geohashCellSize returns the width and height in degrees of a geohash cell of the given length.
+func geohashCellSize(precision int) (width, height float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 360 / math.Exp2(float64(lonBits)), 180 / math.Exp2(float64(latBits))
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohash encodes a location as a geohash of the given length.
+func geohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	var ch, bit int
+	even := true
+	for len(hash) < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch |= 16 >> bit
+				minLon = mid
+			} else {
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch |= 16 >> bit
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+		} else {
+			hash = append(hash, geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+// distance returns the great-circle distance in meters between two locations.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func normalizeLon(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon < -180 {
+		lon += 360
+	}
+
+	return lon
+}
@@ -0,0 +1,185 @@
+package bbucket
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+type device struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+func deviceLocation(ptr interface{}) (float64, float64, bool) {
+	d := ptr.(*device)
+	return d.Lat, d.Lon, d.Name != ``
+}
+
+func TestGeohash(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Eq(`u4pruydqqvj8`, geohash(57.64911, 10.40744, 12))
+	assert.Eq(`ezs42`, geohash(42.605, -5.603, 5))
+
+	width, height := geohashCellSize(1)
+	assert.Eq(45.0, width)
+	assert.Eq(45.0, height)
+}
+
+func TestCover(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, box := range []BBox{
+		{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180},
+		{MinLat: -90, MinLon: 179, MaxLat: 90, MaxLon: 178},
+		{MinLat: 52.37, MinLon: 4.89, MaxLat: 52.37, MaxLon: 4.89},
+		{MinLat: -10, MinLon: 170, MaxLat: 10, MaxLon: -170},
+	} {
+		cells := box.cover()
+		assert.Eq(true, len(cells) > 0 && len(cells) <= maxCoverCells)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	// Amsterdam to Paris is about 430 km
+	d := distance(52.3676, 4.9041, 48.8566, 2.3522)
+	assert.Eq(true, math.Abs(d-430000) < 5000)
+	assert.Eq(0.0, distance(1, 2, 1, 2))
+}
+
+func TestGeoIndex(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	br, err := Open(br.DB, br.Bucket, WithIndex(GeoIndex(`location`, &device{}, deviceLocation)))
+	if err != nil {
+		panic(err)
+	}
+
+	devices := map[string]device{
+		`dam`:      {`dam`, 52.3731, 4.8926},
+		`centraal`: {`centraal`, 52.3791, 4.9003},
+		`utrecht`:  {`utrecht`, 52.0907, 5.1214},
+		`fiji`:     {`fiji`, -17.7134, 178.0650},
+		`samoa`:    {`samoa`, -13.7590, -172.1046},
+		`nowhere`:  {``, 52.3731, 4.8926},
+	}
+	for key, d := range devices {
+		assert.New(t).NoError(br.Create([]byte(key), d))
+	}
+
+	near := func(lat, lon, radius float64) ([]string, []float64) {
+		var keys []string
+		var distances []float64
+		err := br.Near(lat, lon, radius, &device{}, func(key []byte, ptr interface{}, d float64) error {
+			keys = append(keys, ptr.(*device).Name)
+			distances = append(distances, d)
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+		return keys, distances
+	}
+
+	within := func(box BBox) []string {
+		keys := []string{}
+		err := br.Within(box, &device{}, func(key []byte, ptr interface{}) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+		return keys
+	}
+
+	t.Run(`near`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		keys, distances := near(52.3731, 4.8926, 1000)
+		assert.Cmp([]string{`dam`, `centraal`}, keys)
+		assert.Eq(0.0, distances[0])
+		assert.Eq(true, distances[1] > 700 && distances[1] < 900)
+
+		keys, _ = near(52.3731, 4.8926, 50000)
+		assert.Cmp([]string{`dam`, `centraal`, `utrecht`}, keys)
+
+		keys, _ = near(52.3731, 4.8926, 10)
+		assert.Cmp([]string{`dam`}, keys)
+
+		// across the antimeridian
+		keys, _ = near(-15, 180, 1000000)
+		assert.Cmp([]string{`fiji`, `samoa`}, keys)
+
+		keys, _ = near(89.9, 0, 1000)
+		assert.Eq(0, len(keys))
+	})
+
+	t.Run(`within`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Cmp([]string{`centraal`, `dam`, `utrecht`}, within(BBox{MinLat: 50, MinLon: 3, MaxLat: 54, MaxLon: 7}))
+		assert.Cmp([]string{`centraal`}, within(BBox{MinLat: 52.375, MinLon: 4.89, MaxLat: 52.38, MaxLon: 4.91}))
+		assert.Cmp([]string{`fiji`, `samoa`}, within(BBox{MinLat: -20, MinLon: 170, MaxLat: -10, MaxLon: -170}))
+		assert.Cmp([]string{}, within(BBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 1}))
+	})
+
+	t.Run(`kept in sync`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(br.Patch([]byte(`utrecht`), []byte(`{"lat":52.3732,"lon":4.8927}`)))
+		assert.NoError(br.Delete([]byte(`centraal`)))
+
+		keys, _ := near(52.3731, 4.8926, 1000)
+		assert.Cmp([]string{`dam`, `utrecht`}, keys)
+	})
+
+	t.Run(`invalid`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		nearErr := func(lat, lon, radius float64) error {
+			return br.Near(lat, lon, radius, &device{}, func([]byte, interface{}, float64) error { return nil })
+		}
+		withinErr := func(box BBox) error {
+			return br.Within(box, &device{}, func([]byte, interface{}) error { return nil })
+		}
+
+		nan, inf := math.NaN(), math.Inf(1)
+		assert.Eq(ErrInvalidLocation, nearErr(nan, 0, 1000))
+		assert.Eq(ErrInvalidLocation, nearErr(0, nan, 1000))
+		assert.Eq(ErrInvalidLocation, nearErr(0, 0, nan))
+		assert.Eq(ErrInvalidLocation, nearErr(inf, 0, 1000))
+		assert.Eq(ErrInvalidLocation, nearErr(0, 0, inf))
+		assert.Eq(ErrInvalidLocation, nearErr(0, 0, -1))
+		assert.Eq(ErrInvalidLocation, nearErr(91, 0, 1000))
+		assert.Eq(ErrInvalidLocation, nearErr(0, -181, 1000))
+
+		assert.Eq(ErrInvalidLocation, withinErr(BBox{MinLat: nan, MinLon: 0, MaxLat: 1, MaxLon: 1}))
+		assert.Eq(ErrInvalidLocation, withinErr(BBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: -inf}))
+		assert.Eq(ErrInvalidLocation, withinErr(BBox{MinLat: -100, MinLon: 0, MaxLat: 1, MaxLon: 1}))
+		assert.Eq(ErrInvalidLocation, withinErr(BBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 200}))
+		assert.Eq(ErrInvalidLocation, withinErr(BBox{MinLat: 1, MinLon: 0, MaxLat: 0, MaxLon: 1}))
+
+		assert.NoError(nearErr(0, 0, 0))
+		assert.NoError(nearErr(90, 180, 1e12))
+		assert.NoError(withinErr(BBox{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180}))
+		assert.NoError(withinErr(BBox{MinLat: -90, MinLon: 180, MaxLat: 90, MaxLon: -180}))
+
+		keys, _ := near(0, 0, 1e12)
+		assert.Cmp([]string{`dam`, `utrecht`, `fiji`, `samoa`}, keys)
+	})
+
+	t.Run(`no index`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := Bucket{DB: br.DB, Bucket: br.Bucket}.Within(BBox{}, &device{}, func([]byte, interface{}) error { return nil })
+		assert.Eq(true, errors.Is(err, ErrNoIndex))
+	})
+}
@@ -0,0 +1,270 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+
+	"go.etcd.io/bbolt"
+)
+
+// Index maintains a sibling bucket with entries derived from the objects in a Bucket.
+// Indexes are updated in the same transaction as every write through the Bucket.
+// Writes made directly in BucketUpdate or BucketBatch are not indexed, use Reindex after them.
+type Index interface {
+	// Name returns the name of the index, which must be unique within the Bucket.
+	// Entries are stored in the bucket "<bucket>.index.<name>".
+	Name() string
+	// Update changes the entries in b when the object at key changes from old to data.
+	// old is nil for new objects and data is nil for deleted objects.
+	Update(b *bbolt.Bucket, key, old, data []byte) error
+}
+
+// WithIndex maintains idx for the Bucket. Open creates the index bucket
+// and fills it from the existing objects if it doesn't exist yet.
+func WithIndex(idx Index) Option {
+	return func(br *Bucket) {
+		br.indexes = append(br.indexes, idx)
+	}
+}
+
+// Reindex rebuilds all indexes of the Bucket from the stored objects in a single transaction.
+func (br Bucket) Reindex() error {
+	return br.DB.Update(func(tx *bbolt.Tx) error {
+		for _, idx := range br.indexes {
+			name := br.indexName(idx)
+			if tx.Bucket(name) != nil {
+				err := tx.DeleteBucket(name)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return br.createIndexes(tx)
+	})
+}
+
+func (br Bucket) indexName(idx Index) []byte {
+	return br.sibling("index." + idx.Name())
+}
+
+// createIndexes creates and fills the index buckets that don't exist yet.
+func (br Bucket) createIndexes(tx *bbolt.Tx) error {
+	b := tx.Bucket(br.Bucket)
+	if b == nil {
+		return ErrBucketNotFound
+	}
+
+	for _, idx := range br.indexes {
+		name := br.indexName(idx)
+		if tx.Bucket(name) != nil {
+			continue
+		}
+
+		ib, err := tx.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+
+		err = b.ForEach(func(k, v []byte) error {
+			return idx.Update(ib, k, nil, v)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateIndexes passes a change of the object at key to every index.
+func (br Bucket) updateIndexes(b *bbolt.Bucket, key, data []byte) error {
+	if len(br.indexes) == 0 {
+		return nil
+	}
+
+	old := clone(b.Get(key))
+	for _, idx := range br.indexes {
+		ib := b.Tx().Bucket(br.indexName(idx))
+		if ib == nil {
+			return ErrBucketNotFound
+		}
+
+		err := idx.Update(ib, key, old, data)
+		if err != nil {
+			return br.keyError("Index", key, err)
+		}
+	}
+
+	return nil
+}
+
+// indexBucket returns the bucket of idx within the transaction of r.
+func (r Reader) indexBucket(idx Index) (*bbolt.Bucket, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	ib := r.tx.Bucket(r.br.indexName(idx))
+	if ib == nil {
+		return nil, ErrBucketNotFound
+	}
+
+	return ib, nil
+}
+
+// FieldIndex indexes the objects of a Bucket by the value of a top-level JSON field,
+// so that a Query on that field doesn't have to scan the bucket.
+// Strings, numbers, booleans and null are indexed, other values and objects without the field are not.
+// The index is named after the field.
+func FieldIndex(field string) Index {
+	return &fieldIndex{field: field}
+}
+
+type fieldIndex struct {
+	field string
+}
+
+func (fi *fieldIndex) Name() string {
+	return fi.field
+}
+
+func (fi *fieldIndex) Update(b *bbolt.Bucket, key, old, data []byte) error {
+	oldValue, oldOK := fi.value(old)
+	value, ok := fi.value(data)
+	if oldOK == ok && bytes.Equal(oldValue, value) {
+		return nil
+	}
+
+	if oldOK {
+		err := b.Delete(indexEntry(oldValue, key))
+		if err != nil {
+			return err
+		}
+	}
+
+	if ok {
+		return b.Put(indexEntry(value, key), []byte{})
+	}
+
+	return nil
+}
+
+// value returns the encoded field value of a stored object, if it is indexed.
+// Objects that can't be decoded are not indexed.
+func (fi *fieldIndex) value(data []byte) ([]byte, bool) {
+	if data == nil {
+		return nil, false
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, false
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	v, ok := m[fi.field]
+	if !ok {
+		return nil, false
+	}
+
+	return encodeIndexValue(v)
+}
+
+// Type tags of encoded index values, in the order they sort in.
+const (
+	indexNull byte = iota + 1
+	indexFalse
+	indexTrue
+	indexNumber
+	indexString
+)
+
+// encodeIndexValue encodes a decoded JSON value so that encoded values sort like the values they encode.
+// It returns false for objects and arrays.
+func encodeIndexValue(v interface{}) ([]byte, bool) {
+	switch v := v.(type) {
+	case nil:
+		return []byte{indexNull}, true
+	case bool:
+		if v {
+			return []byte{indexTrue}, true
+		}
+		return []byte{indexFalse}, true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, false
+		}
+		return encodeIndexNumber(f), true
+	case float64:
+		return encodeIndexNumber(v), true
+	case string:
+		return append([]byte{indexString}, v...), true
+	}
+
+	return nil, false
+}
+
+func encodeIndexNumber(f float64) []byte {
+	bits := math.Float64bits(f)
+	if f >= 0 {
+		bits ^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+
+	b := make([]byte, 9)
+	b[0] = indexNumber
+	binary.BigEndian.PutUint64(b[1:], bits)
+	return b
+}
+
+// indexSeparator ends the escaped index value in an entry key. It sorts before any escaped byte.
+var indexSeparator = []byte{0x00, 0x01}
+
+// indexEntry returns the key of an index entry: the escaped value, the separator and the primary key.
+// Escaping 0x00 as 0x00 0xff keeps the order of values and makes the separator unambiguous.
+func indexEntry(value, key []byte) []byte {
+	entry := escapeIndexValue(value)
+	entry = append(entry, indexSeparator...)
+	return append(entry, key...)
+}
+
+// indexPrefix returns the prefix of all entries for value.
+func indexPrefix(value []byte) []byte {
+	return append(escapeIndexValue(value), indexSeparator...)
+}
+
+func escapeIndexValue(value []byte) []byte {
+	escaped := make([]byte, 0, len(value)+2)
+	for _, c := range value {
+		escaped = append(escaped, c)
+		if c == 0x00 {
+			escaped = append(escaped, 0xff)
+		}
+	}
+
+	return escaped
+}
+
+// indexEntryKey returns the primary key of an index entry.
+func indexEntryKey(entry []byte) []byte {
+	for i := 0; i+1 < len(entry); i++ {
+		if entry[i] != 0x00 {
+			continue
+		}
+		if entry[i+1] == indexSeparator[1] {
+			return entry[i+2:]
+		}
+		i++ // skip the escaped 0x00
+	}
+
+	return nil
+}
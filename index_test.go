@@ -0,0 +1,102 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+// indexKeys returns the index values and primary keys stored in an index, as "value=key".
+func indexContents(br Bucket, idx Index) []string {
+	var entries []string
+	_ = br.View(func(r Reader) error {
+		ib, err := r.indexBucket(idx)
+		if err != nil {
+			return err
+		}
+
+		return ib.ForEach(func(k, _ []byte) error {
+			key := indexEntryKey(k)
+			value := k[:len(k)-len(key)-len(indexSeparator)]
+			entries = append(entries, string(value[1:])+`=`+string(key))
+			return nil
+		})
+	})
+
+	return entries
+}
+
+func TestFieldIndex(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	// existing objects are indexed on Open
+	idx := FieldIndex(`a`)
+	br, err := Open(br.DB, br.Bucket, WithIndex(idx))
+	assert.NoError(err)
+	assert.Cmp([]string{`ABC=ABC`, `BCD=BCD`, `CDE=CDE`}, indexContents(br, idx))
+
+	assert.NoError(br.Create([]byte(`1`), testStruct4))
+	assert.NoError(br.Update([]byte(`1`), &testStruct{}, func(ptr interface{}) (interface{}, error) {
+		return testStruct{`XXX`, 1}, nil
+	}))
+	assert.NoError(br.Delete(testStruct2.Key()))
+	assert.NoError(br.Patch(testStruct3.Key(), []byte(`{"a":"ZZZ"}`)))
+	assert.Cmp([]string{`ABC=ABC`, `XXX=1`, `ZZZ=CDE`}, indexContents(br, idx))
+
+	assert.NoError(br.UpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+		obj := *ptr.(*testStruct)
+		return []byte(obj.ID), obj, nil
+	}))
+	assert.Cmp([]string{`ABC=ABC`, `XXX=XXX`, `ZZZ=ZZZ`}, indexContents(br, idx))
+
+	// a failed write leaves the index unchanged
+	err = br.CreateAll([]testStruct{testStruct5, testStruct1}, func(obj interface{}) ([]byte, error) {
+		return obj.(testStruct).Key(), nil
+	})
+	assert.Error(err)
+	assert.Cmp([]string{`ABC=ABC`, `XXX=XXX`, `ZZZ=ZZZ`}, indexContents(br, idx))
+}
+
+func TestEncodeIndexValue(t *testing.T) {
+	assert := assert.New(t)
+
+	values := []interface{}{nil, false, true, -1e10, -1.5, json.Number(`0`), 2.0, json.Number(`1e10`), ``, "a", "a\x00", "a\x00b", "ab", "b"}
+	var encoded [][]byte
+	for _, v := range values {
+		e, ok := encodeIndexValue(v)
+		assert.Eq(true, ok)
+		encoded = append(encoded, indexEntry(e, []byte(`key`)))
+	}
+
+	assert.Eq(true, sort.SliceIsSorted(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	}))
+
+	for _, e := range encoded {
+		assert.Eq(`key`, string(indexEntryKey(e)))
+	}
+
+	_, ok := encodeIndexValue([]interface{}{})
+	assert.Eq(false, ok)
+}
+
+func TestIndexBucketMissing(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	WithIndex(FieldIndex(`b`))(&br)
+	err := br.Create(testStruct4.Key(), testStruct4)
+	assert.Eq(ErrBucketNotFound, err)
+
+	assert.NoError(br.DB.Update(func(tx *bbolt.Tx) error {
+		return br.createIndexes(tx)
+	}))
+	assert.NoError(br.Create(testStruct4.Key(), testStruct4))
+}
@@ -0,0 +1,111 @@
+package bbucket
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// Iterator streams objects from the bucket.
+// Iterator.Seq has the signature of iter.Seq2[[]byte, interface{}],
+// so with Go 1.23 or later it can be used with range-over-func:
+//
+//	it := myBucket.All(&Object{})
+//	for key, ptr := range it.Seq {
+//	    obj := *ptr.(*Object)
+//	}
+//	if err := it.Err(); err != nil {
+//	    return err
+//	}
+//
+// Each iteration runs in its own read transaction, which is closed when the
+// loop ends, including on break.
+type Iterator struct {
+	br     Bucket
+	dst    interface{}
+	bounds bounds
+	err    error
+}
+
+// All returns an Iterator over all objects in the bucket.
+// Objects are decoded into newly allocated values of the same type as dst.
+func (br Bucket) All(dst interface{}) *Iterator {
+	return &Iterator{br: br, dst: dst}
+}
+
+// Range returns an Iterator over objects with from <= key < to.
+// A nil from or to leaves that side of the range open.
+func (br Bucket) Range(from, to []byte, dst interface{}) *Iterator {
+	return &Iterator{br: br, dst: dst, bounds: bounds{from: from, to: to}}
+}
+
+// Prefix returns an Iterator over objects whose key starts with p.
+func (br Bucket) Prefix(p []byte, dst interface{}) *Iterator {
+	return &Iterator{br: br, dst: dst, bounds: prefixBounds(p)}
+}
+
+// Seq yields copies of the keys and pointers to the decoded objects.
+// It stops at the first error, which is then returned by Err.
+func (it *Iterator) Seq(yield func(key []byte, ptr interface{}) bool) {
+	it.err = it.br.View(func(r Reader) error {
+		return r.scan("Seq", it.bounds, scanOptions{}, it.dst, func(k []byte, ptr interface{}) (bool, error) {
+			return !yield(append([]byte{}, k...), ptr), nil
+		})
+	})
+}
+
+// Err returns the error that stopped the last iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// bounds restricts a cursor walk to a range of keys.
+// from is inclusive, to is exclusive and nil means unbounded.
+type bounds struct {
+	from, to []byte
+}
+
+func prefixBounds(p []byte) bounds {
+	return bounds{from: p, to: prefixEnd(p)}
+}
+
+// prefixEnd returns the first key after all keys starting with p.
+// It returns nil if there is no such key.
+func prefixEnd(p []byte) []byte {
+	end := append([]byte{}, p...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+func (bd bounds) first(c *bbolt.Cursor) (key, value []byte) {
+	if bd.from == nil {
+		return c.First()
+	}
+
+	return c.Seek(bd.from)
+}
+
+// last positions c on the last key before bd.to.
+func (bd bounds) last(c *bbolt.Cursor) (key, value []byte) {
+	if bd.to == nil {
+		return c.Last()
+	}
+
+	k, _ := c.Seek(bd.to)
+	if k == nil {
+		return c.Last()
+	}
+
+	return c.Prev()
+}
+
+func (bd bounds) contains(key []byte) bool {
+	return (bd.from == nil || bytes.Compare(key, bd.from) >= 0) &&
+		(bd.to == nil || bytes.Compare(key, bd.to) < 0)
+}
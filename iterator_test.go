@@ -0,0 +1,109 @@
+package bbucket
+
+import (
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func collect(it *Iterator) ([]string, []testStruct) {
+	var keys []string
+	var objects []testStruct
+	it.Seq(func(key []byte, ptr interface{}) bool {
+		keys = append(keys, string(key))
+		objects = append(objects, *ptr.(*testStruct))
+		return true
+	})
+
+	return keys, objects
+}
+
+func TestAll(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`all`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		it := br.All(&testStruct{})
+		keys, objects := collect(it)
+		assert.NoError(it.Err())
+		assert.Cmp([]string{`ABC`, `BCD`, `CDE`}, keys)
+		assert.Cmp(testData, objects)
+	})
+
+	t.Run(`break closes transaction`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		it := br.All(&testStruct{})
+		var objects []testStruct
+		it.Seq(func(key []byte, ptr interface{}) bool {
+			objects = append(objects, *ptr.(*testStruct))
+			return false
+		})
+		assert.NoError(it.Err())
+		assert.Cmp([]testStruct{testStruct1}, objects)
+		assert.Eq(0, br.DB.Stats().OpenTxN)
+	})
+
+	t.Run(`decode error`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+			return b.Put([]byte(`BBB`), []byte(`{`))
+		})
+		assert.NoError(err)
+		defer func() {
+			_ = br.Delete([]byte(`BBB`))
+		}()
+
+		it := br.All(&testStruct{})
+		keys, _ := collect(it)
+		assert.Error(it.Err())
+		assert.Cmp([]string{`ABC`}, keys)
+	})
+}
+
+func TestRange(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`from and to`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		it := br.Range([]byte(`B`), []byte(`CDE`), &testStruct{})
+		keys, objects := collect(it)
+		assert.NoError(it.Err())
+		assert.Cmp([]string{`BCD`}, keys)
+		assert.Cmp([]testStruct{testStruct2}, objects)
+	})
+
+	t.Run(`open ends`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		keys, _ := collect(br.Range(nil, []byte(`BCD`), &testStruct{}))
+		assert.Cmp([]string{`ABC`}, keys)
+
+		keys, _ = collect(br.Range([]byte(`BCD`), nil, &testStruct{}))
+		assert.Cmp([]string{`BCD`, `CDE`}, keys)
+	})
+}
+
+func TestPrefix(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	err := br.Create([]byte("AB\xff"), testStruct4)
+	assert.NoError(err)
+
+	it := br.Prefix([]byte(`AB`), &testStruct{})
+	keys, objects := collect(it)
+	assert.NoError(it.Err())
+	assert.Cmp([]string{`ABC`, "AB\xff"}, keys)
+	assert.Cmp([]testStruct{testStruct1, testStruct4}, objects)
+
+	assert.Cmp([]byte(`AC`), prefixEnd([]byte("AB\xff")))
+	assert.Eq([]byte(nil), prefixEnd([]byte("\xff\xff")))
+}
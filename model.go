@@ -0,0 +1,216 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go.etcd.io/bbolt"
+)
+
+// Keyer is implemented by objects that know their own key.
+type Keyer interface {
+	Key() []byte
+}
+
+// Models derives keys from the objects themselves instead of taking them as an argument.
+// An object either implements Keyer, or is a struct with a field tagged `bbucket:"key"`.
+// Tagged fields must be exported and can be a string, a []byte or an integer, which is encoded using Itob.
+// Integer key fields that are zero are filled using NextSequence.
+// Pass a pointer to have the generated key written back to your object.
+type Models struct {
+	br Bucket
+}
+
+// Models returns a Models for the bucket.
+func (br Bucket) Models() Models {
+	return Models{br: br}
+}
+
+// Create stores a new object in the bucket.
+// If the key already exists, it returns ErrObjectAlreadyExists
+func (m Models) Create(obj interface{}) error {
+	var setKey func()
+	err := m.br.write(func(b *bbolt.Bucket) error {
+		var err error
+		setKey, err = m.put(b, "Create", reflect.ValueOf(obj), false)
+		return err
+	})
+	if err == nil && setKey != nil {
+		setKey()
+	}
+
+	return err
+}
+
+// Save stores an object in the bucket, overwriting any existing object with the same key.
+func (m Models) Save(obj interface{}) error {
+	var setKey func()
+	err := m.br.write(func(b *bbolt.Bucket) error {
+		var err error
+		setKey, err = m.put(b, "Save", reflect.ValueOf(obj), true)
+		return err
+	})
+	if err == nil && setKey != nil {
+		setKey()
+	}
+
+	return err
+}
+
+// CreateAll stores multiple objects in the bucket.
+// If any key already exists, it returns ErrObjectAlreadyExists
+// The argument must be a slice of objects or of pointers to objects.
+func (m Models) CreateAll(objs interface{}) error {
+	var setKeys []func()
+	err := m.br.BucketUpdate(func(b *bbolt.Bucket) error {
+		s := reflect.ValueOf(objs)
+		if s.Kind() != reflect.Slice {
+			return ErrNonSliceArgument
+		}
+
+		for i := 0; i < s.Len(); i++ {
+			setKey, err := m.put(b, "CreateAll", s.Index(i), false)
+			if err != nil {
+				return err
+			}
+
+			if setKey != nil {
+				setKeys = append(setKeys, setKey)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, setKey := range setKeys {
+		setKey()
+	}
+
+	return nil
+}
+
+// put stores an object. If its key was generated, setKey writes it back to the object.
+// This is left to the caller, so that nothing is written back if the transaction fails or is retried.
+func (m Models) put(b *bbolt.Bucket, op string, v reflect.Value, overwrite bool) (setKey func(), err error) {
+	key, obj, setKey, err := modelKey(b, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if !overwrite && b.Get(key) != nil {
+		return nil, m.br.keyError(op, key, ErrObjectAlreadyExists)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, m.br.keyError(op, key, err)
+	}
+
+	return setKey, m.br.put(b, key, data)
+}
+
+// modelKey returns the key of v and the object to be stored.
+// If the key has to be generated, the object is a copy of v with the key set,
+// and setKey sets the key on v itself if possible.
+func modelKey(b *bbolt.Bucket, v reflect.Value) (key []byte, obj interface{}, setKey func(), err error) {
+	if !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil, nil, ErrNoKey
+	}
+
+	if k, ok := v.Interface().(Keyer); ok {
+		return k.Key(), v.Interface(), nil, nil
+	}
+
+	if v.CanAddr() {
+		if k, ok := v.Addr().Interface().(Keyer); ok {
+			return k.Key(), v.Interface(), nil, nil
+		}
+	}
+
+	s := v
+	for s.Kind() == reflect.Ptr {
+		if s.IsNil() {
+			return nil, nil, nil, ErrNoKey
+		}
+		s = s.Elem()
+	}
+
+	if s.Kind() != reflect.Struct {
+		return nil, nil, nil, ErrNoKey
+	}
+
+	i, err := keyField(s.Type())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	f := s.Field(i)
+	switch f.Kind() {
+	case reflect.String:
+		return []byte(f.String()), v.Interface(), nil, nil
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, nil, nil, ErrNoKey
+		}
+		return f.Bytes(), v.Interface(), nil, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f.Int() != 0 {
+			return Itob(int(f.Int())), v.Interface(), nil, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f.Uint() != 0 {
+			return Itob(int(f.Uint())), v.Interface(), nil, nil
+		}
+	default:
+		return nil, nil, nil, ErrNoKey
+	}
+
+	// zero integer key: generate one
+	seq, err := b.NextSequence()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c := reflect.New(s.Type()).Elem()
+	c.Set(s)
+	setSequence(c.Field(i), seq)
+
+	if f.CanSet() {
+		setKey = func() {
+			setSequence(f, seq)
+		}
+	}
+
+	return Itob(int(seq)), c.Interface(), setKey, nil
+}
+
+func setSequence(f reflect.Value, seq uint64) {
+	if f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64 {
+		f.SetUint(seq)
+	} else {
+		f.SetInt(int64(seq))
+	}
+}
+
+// keyField returns the index of the field tagged `bbucket:"key"`.
+// Unexported fields can't be set and aren't stored, so they can't be the key.
+func keyField(t reflect.Type) (int, error) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("bbucket") != "key" {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			return 0, fmt.Errorf("%w: key field %s is unexported", ErrNoKey, f.Name)
+		}
+
+		return i, nil
+	}
+
+	return 0, ErrNoKey
+}
@@ -0,0 +1,150 @@
+package bbucket
+
+import (
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+type taggedStruct struct {
+	Name string `json:"name" bbucket:"key"`
+	Data int    `json:"data"`
+}
+
+type autoStruct struct {
+	ID   uint64 `json:"id" bbucket:"key"`
+	Data int    `json:"data"`
+}
+
+func TestModelsCreate(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+	m := br.Models()
+
+	t.Run(`keyer`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := m.Create(testStruct4)
+		assert.NoError(err)
+
+		actual, err := getTestStruct(br, testStruct4.Key())
+		assert.NoError(err)
+		assert.Eq(testStruct4, actual)
+
+		err = m.Create(testStruct4)
+		assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
+	})
+
+	t.Run(`tagged string field`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		expected := taggedStruct{Name: `tagged`, Data: 5}
+		err := m.Create(expected)
+		assert.NoError(err)
+
+		var actual taggedStruct
+		err = br.Get([]byte(`tagged`), &actual)
+		assert.NoError(err)
+		assert.Eq(expected, actual)
+	})
+
+	t.Run(`auto increment`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		obj := autoStruct{Data: 1}
+		err := m.Create(&obj)
+		assert.NoError(err)
+		assert.Eq(uint64(1), obj.ID)
+
+		var actual autoStruct
+		err = br.Get(Itob(1), &actual)
+		assert.NoError(err)
+		assert.Eq(obj, actual)
+
+		// non-pointers are stored with the generated key, but not written back
+		obj2 := autoStruct{Data: 2}
+		err = m.Create(obj2)
+		assert.NoError(err)
+		assert.Eq(uint64(0), obj2.ID)
+
+		err = br.Get(Itob(2), &actual)
+		assert.NoError(err)
+		assert.Eq(autoStruct{ID: 2, Data: 2}, actual)
+	})
+
+	t.Run(`no key`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := m.Create(struct{ A int }{})
+		assert.Eq(ErrNoKey, err)
+
+		err = m.Create((*autoStruct)(nil))
+		assert.Eq(ErrNoKey, err)
+
+		err = m.Create(5)
+		assert.Eq(ErrNoKey, err)
+
+		err = m.Create(&struct {
+			id int `bbucket:"key"`
+		}{})
+		assert.Eq(true, errors.Is(err, ErrNoKey))
+
+		err = m.Create(struct {
+			name string `bbucket:"key"`
+		}{`unexported`})
+		assert.Eq(true, errors.Is(err, ErrNoKey))
+	})
+}
+
+func TestModelsSave(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	expected := testStruct1
+	expected.Data = 42
+
+	err := br.Models().Save(expected)
+	assert.NoError(err)
+
+	actual, err := getTestStruct(br, expected.Key())
+	assert.NoError(err)
+	assert.Eq(expected, actual)
+}
+
+func TestModelsCreateAll(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`auto increment`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		objects := []autoStruct{{Data: 1}, {Data: 2}, {ID: 10, Data: 3}}
+		err := br.Models().CreateAll(objects)
+		assert.NoError(err)
+		assert.Cmp([]autoStruct{{1, 1}, {2, 2}, {10, 3}}, objects)
+
+		var actual autoStruct
+		err = br.Get(Itob(10), &actual)
+		assert.NoError(err)
+		assert.Eq(objects[2], actual)
+	})
+
+	t.Run(`duplicate key`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Models().CreateAll([]testStruct{testStruct5, testStruct1})
+		assert.Eq(true, errors.Is(err, ErrObjectAlreadyExists))
+
+		_, err = getTestStruct(br, testStruct5.Key())
+		assert.Eq(ErrObjectNotFound, err)
+	})
+
+	t.Run(`non-slice`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Models().CreateAll(testStruct5)
+		assert.Eq(ErrNonSliceArgument, err)
+	})
+}
@@ -0,0 +1,377 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation.
+// Op is one of add, remove, replace, move, copy and test.
+// Path and From are RFC 6901 JSON Pointers.
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch applies an RFC 7396 JSON merge patch to an object in a single transaction.
+// It works on the stored JSON directly, so no destination type is needed.
+// If the key does not exist, it return ErrObjectNotFound
+func (br Bucket) Patch(key []byte, patch []byte) error {
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return err
+	}
+
+	return br.patch("Patch", key, func(doc interface{}) (interface{}, error) {
+		return mergePatch(doc, p), nil
+	})
+}
+
+// PatchOps applies RFC 6902 JSON Patch operations to an object in a single transaction.
+// If any operation fails, including a failing test operation, the object is left unchanged.
+// If the key does not exist, it return ErrObjectNotFound
+func (br Bucket) PatchOps(key []byte, ops []PatchOp) error {
+	return br.patch("PatchOps", key, func(doc interface{}) (interface{}, error) {
+		var err error
+		for _, op := range ops {
+			doc, err = op.apply(doc)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return doc, nil
+	})
+}
+
+func (br Bucket) patch(op string, key []byte, f func(doc interface{}) (interface{}, error)) error {
+	return br.write(func(b *bbolt.Bucket) error {
+		data := b.Get(key)
+		if data == nil {
+			return br.keyError(op, key, ErrObjectNotFound)
+		}
+
+		doc, err := decodeJSON(data)
+		if err != nil {
+			return br.keyError(op, key, err)
+		}
+
+		doc, err = f(doc)
+		if err != nil {
+			return br.keyError(op, key, err)
+		}
+
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return br.keyError(op, key, err)
+		}
+
+		return br.put(b, key, data)
+	})
+}
+
+// decodeJSON decodes data into a generic value, keeping numbers as they are.
+func decodeJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	err := d.Decode(&v)
+	return v, err
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+
+	return t
+}
+
+func (op PatchOp) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %s %q: missing value", ErrInvalidPatch, op.Op, op.Path)
+		}
+
+		value, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return modify(doc, path, addTo(value))
+		case "replace":
+			if len(path) == 0 {
+				return value, nil // replace the whole document
+			}
+			doc, err = modify(doc, path, removeFrom)
+			if err != nil {
+				return nil, err
+			}
+			return modify(doc, path, addTo(value))
+		}
+
+		actual, err := lookup(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(actual, value) {
+			return nil, fmt.Errorf("%w: %q", ErrPatchTestFailed, op.Path)
+		}
+		return doc, nil
+
+	case "remove":
+		return modify(doc, path, removeFrom)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := lookup(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			return modify(doc, path, addTo(copyJSON(value)))
+		}
+
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPatch, op.From)
+		}
+
+		doc, err = modify(doc, from, removeFrom)
+		if err != nil {
+			return nil, err
+		}
+		return modify(doc, path, addTo(value))
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: invalid pointer %q", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+
+	return tokens, nil
+}
+
+// root is passed to the functions of modify for an empty path.
+type root struct{}
+
+// modify calls f with the container that holds the last token of path,
+// and returns doc with that container replaced by the result of f.
+func modify(doc interface{}, path []string, f func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 0 {
+		return f(root{}, "")
+	}
+
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+
+	child, err := lookup(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = modify(child, path[1:], f)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		c[path[0]] = child
+	case []interface{}:
+		i, _ := arrayIndex(c, path[0], false)
+		c[i] = child
+	}
+
+	return doc, nil
+}
+
+func addTo(value interface{}) func(container interface{}, token string) (interface{}, error) {
+	return func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case root:
+			return value, nil
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(c, token, true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+
+		return nil, fmt.Errorf("%w: cannot add %q to a value", ErrInvalidPatch, token)
+	}
+}
+
+func removeFrom(container interface{}, token string) (interface{}, error) {
+	switch c := container.(type) {
+	case root:
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	case map[string]interface{}:
+		if _, ok := c[token]; !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+		}
+		delete(c, token)
+		return c, nil
+	case []interface{}:
+		i, err := arrayIndex(c, token, false)
+		if err != nil {
+			return nil, err
+		}
+		return append(c[:i], c[i+1:]...), nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot remove %q from a value", ErrInvalidPatch, token)
+}
+
+func lookup(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(c, token, false)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+		}
+	}
+
+	return doc, nil
+}
+
+// arrayIndex parses an array index token. If end is true, "-" and len(a) are allowed.
+func arrayIndex(a []interface{}, token string, end bool) (int, error) {
+	if end && token == "-" {
+		return len(a), nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) || i > len(a) || i == len(a) && !end {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	return i, nil
+}
+
+func copyJSON(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			m[k] = copyJSON(v)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(c))
+		for i, v := range c {
+			a[i] = copyJSON(v)
+		}
+		return a
+	}
+
+	return v
+}
+
+// jsonEqual compares decoded JSON values, treating numbers with the same value as equal.
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
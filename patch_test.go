@@ -0,0 +1,127 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func getRaw(br Bucket, key string) string {
+	var data []byte
+	err := br.BucketView(func(b *bbolt.Bucket) error {
+		data = append([]byte{}, b.Get([]byte(key))...)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
+
+func putRaw(br Bucket, key, data string) {
+	err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.Put([]byte(key), []byte(data))
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`merge patch`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		putRaw(br, `doc`, `{"a":"b","c":{"d":"e","f":"g"},"n":12345678901234567890}`)
+
+		err := br.Patch([]byte(`doc`), []byte(`{"a":"z","c":{"f":null},"x":[1]}`))
+		assert.NoError(err)
+		assert.Eq(`{"a":"z","c":{"d":"e"},"n":12345678901234567890,"x":[1]}`, getRaw(br, `doc`))
+	})
+
+	t.Run(`patch struct`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Patch(testStruct1.Key(), []byte(`{"b":1}`))
+		assert.NoError(err)
+
+		actual, err := getTestStruct(br, testStruct1.Key())
+		assert.NoError(err)
+		assert.Eq(testStruct{`ABC`, 1}, actual)
+	})
+
+	t.Run(`not found`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Patch([]byte(`blablabla`), []byte(`{}`))
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
+	})
+
+	t.Run(`invalid patch`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Patch(testStruct1.Key(), []byte(`{`))
+		assert.Error(err)
+	})
+}
+
+func TestPatchOps(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	ops := func(s string) []PatchOp {
+		var ops []PatchOp
+		err := json.Unmarshal([]byte(s), &ops)
+		if err != nil {
+			panic(err)
+		}
+		return ops
+	}
+
+	tests := []struct {
+		name     string
+		doc      string
+		ops      string
+		expected string
+		err      error
+	}{
+		{`add`, `{"a":[1,2]}`, `[{"op":"add","path":"/a/1","value":9},{"op":"add","path":"/a/-","value":3},{"op":"add","path":"/b~1c","value":null}]`, `{"a":[1,9,2,3],"b/c":null}`, nil},
+		{`remove`, `{"a":[1,2],"b":1}`, `[{"op":"remove","path":"/a/0"},{"op":"remove","path":"/b"}]`, `{"a":[2]}`, nil},
+		{`replace`, `{"a":{"b":1}}`, `[{"op":"replace","path":"/a/b","value":"x"}]`, `{"a":{"b":"x"}}`, nil},
+		{`replace root`, `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`, nil},
+		{`move`, `{"a":{"b":1},"c":[]}`, `[{"op":"move","from":"/a/b","path":"/c/0"}]`, `{"a":{},"c":[1]}`, nil},
+		{`copy`, `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`, nil},
+		{`test`, `{"a":{"b":1.0}}`, `[{"op":"test","path":"/a","value":{"b":1}}]`, `{"a":{"b":1.0}}`, nil},
+		{`test failed`, `{"a":1}`, `[{"op":"remove","path":"/a"},{"op":"test","path":"/a","value":1}]`, `{"a":1}`, ErrInvalidPatch},
+		{`test mismatch`, `{"a":1}`, `[{"op":"test","path":"/a","value":"1"}]`, `{"a":1}`, ErrPatchTestFailed},
+		{`replace missing`, `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, `{"a":1}`, ErrInvalidPatch},
+		{`index out of range`, `{"a":[]}`, `[{"op":"add","path":"/a/1","value":1}]`, `{"a":[]}`, ErrInvalidPatch},
+		{`leading zero`, `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, `{"a":[1,2]}`, ErrInvalidPatch},
+		{`move into itself`, `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, `{"a":{"b":1}}`, ErrInvalidPatch},
+		{`missing value`, `{"a":1}`, `[{"op":"add","path":"/b"}]`, `{"a":1}`, ErrInvalidPatch},
+		{`add to null`, `{"a":null}`, `[{"op":"add","path":"/a/b","value":1}]`, `{"a":null}`, ErrInvalidPatch},
+		{`add root`, `{"a":1}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`, nil},
+		{`unknown op`, `{"a":1}`, `[{"op":"bla","path":"/a"}]`, `{"a":1}`, ErrInvalidPatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			putRaw(br, `doc`, test.doc)
+			err := br.PatchOps([]byte(`doc`), ops(test.ops))
+			if test.err == nil {
+				assert.NoError(err)
+			} else {
+				assert.Eq(true, errors.Is(err, test.err))
+			}
+			assert.Eq(test.expected, getRaw(br, `doc`))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"errors"
+
+	"go.etcd.io/bbolt"
+)
+
+// OnDecodeError enables lenient scanning.
+// Objects that cannot be decoded are skipped by GetAll, Find, UpdateAll and iterators
+// instead of aborting the operation. The decode error, a *KeyError, is passed to f.
+// UpdateAll leaves skipped objects untouched.
+func OnDecodeError(f func(err error)) Option {
+	return func(br *Bucket) {
+		br.onDecodeError = f
+	}
+}
+
+// QuarantinedObject is stored in the quarantine bucket for every object moved by Quarantine.
+type QuarantinedObject struct {
+	Data  []byte `json:"data"`
+	Error string `json:"error"`
+}
+
+// Quarantine moves all objects that cannot be decoded into dst to the quarantine bucket.
+// The quarantined objects keep their key and record the decode error.
+// It returns the number of objects that were moved.
+func (br Bucket) Quarantine(dst interface{}) (int, error) {
+	n := 0
+	err := br.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(br.Bucket)
+		if b == nil {
+			return ErrBucketNotFound
+		}
+
+		var q *bbolt.Bucket
+		var bad [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			decodeErr := br.decode(v, fresh(dst))
+			if decodeErr == nil {
+				return nil
+			}
+
+			var invalid *json.InvalidUnmarshalError
+			if errors.As(decodeErr, &invalid) {
+				return decodeErr
+			}
+
+			if q == nil {
+				var err error
+				q, err = tx.CreateBucketIfNotExists(br.quarantineName())
+				if err != nil {
+					return err
+				}
+			}
+
+			data, err := json.Marshal(QuarantinedObject{Data: v, Error: decodeErr.Error()})
+			if err != nil {
+				return err
+			}
+
+			bad = append(bad, append([]byte{}, k...))
+			return q.Put(k, data)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range bad {
+			err := br.del(b, k)
+			if err != nil {
+				return err
+			}
+		}
+
+		n = len(bad)
+		return nil
+	})
+
+	return n, err
+}
+
+// Quarantined returns a Bucket holding the QuarantinedObjects moved by Quarantine.
+func (br Bucket) Quarantined() (Bucket, error) {
+	return Open(br.DB, br.quarantineName())
+}
+
+func (br Bucket) quarantineName() []byte {
+	return br.sibling("quarantine")
+}
+
+// sibling returns the name of a top-level bucket that belongs to this bucket.
+func (br Bucket) sibling(name string) []byte {
+	return append(append(append([]byte{}, br.Bucket...), '.'), name...)
+}
@@ -0,0 +1,117 @@
+package bbucket
+
+import (
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func putCorrupt(br Bucket, key string) error {
+	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.Put([]byte(key), []byte(`{"a":`))
+	})
+}
+
+func TestOnDecodeError(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	var errs []error
+	OnDecodeError(func(err error) {
+		errs = append(errs, err)
+	})(&br)
+
+	err := putCorrupt(br, `BBB`)
+	if err != nil {
+		panic(err)
+	}
+
+	t.Run(`get all skips`, func(t *testing.T) {
+		assert := assert.New(t)
+		errs = nil
+
+		actual := getAllTestStructs(br)
+		assert.Cmp(testData, actual)
+		assert.Eq(1, len(errs))
+
+		var keyErr *KeyError
+		assert.Eq(true, errors.As(errs[0], &keyErr))
+		assert.Cmp([]byte(`BBB`), keyErr.Key)
+	})
+
+	t.Run(`find skips`, func(t *testing.T) {
+		assert := assert.New(t)
+		errs = nil
+
+		var keys []string
+		err := br.Find(&testStruct{}, func(key []byte, ptr interface{}) (bool, error) {
+			keys = append(keys, string(key))
+			return false, nil
+		})
+		assert.Eq(ErrObjectNotFound, err)
+		assert.Cmp([]string{`ABC`, `BCD`, `CDE`}, keys)
+		assert.Eq(1, len(errs))
+	})
+
+	t.Run(`update all leaves corrupt object untouched`, func(t *testing.T) {
+		assert := assert.New(t)
+		errs = nil
+
+		err := br.UpdateAll(&testStruct{}, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*testStruct)
+			return o.Key(), o, nil
+		})
+		assert.NoError(err)
+		assert.Eq(1, len(errs))
+
+		n, err := br.Quarantine(&testStruct{})
+		assert.NoError(err)
+		assert.Eq(1, n)
+	})
+
+	t.Run(`invalid destination is not skipped`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.GetAll(testStruct{}, func(ptr interface{}) error { return nil })
+		assert.Error(err)
+	})
+}
+
+func TestQuarantine(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	_ = br.DB.Update(func(tx *bbolt.Tx) error {
+		_ = tx.DeleteBucket(br.quarantineName())
+		return nil
+	})
+
+	assert.NoError(putCorrupt(br, `BBB`))
+	assert.NoError(putCorrupt(br, `ZZZ`))
+
+	_, err := br.Quarantine(testStruct{})
+	assert.Error(err)
+
+	n, err := br.Quarantine(&testStruct{})
+	assert.NoError(err)
+	assert.Eq(2, n)
+
+	assert.Cmp(testData, getAllTestStructs(br))
+
+	q, err := br.Quarantined()
+	assert.NoError(err)
+	assert.Cmp([]byte(`test.quarantine`), q.Bucket)
+
+	var obj QuarantinedObject
+	err = q.Get([]byte(`ZZZ`), &obj)
+	assert.NoError(err)
+	assert.Cmp([]byte(`{"a":`), obj.Data)
+	assert.Eq(`unexpected end of JSON input`, obj.Error)
+
+	n, err = br.Quarantine(&testStruct{})
+	assert.NoError(err)
+	assert.Eq(0, n)
+}
@@ -0,0 +1,686 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operator compares a field with a value in Query.Where.
+type Operator int
+
+const (
+	Eq Operator = iota
+	Ne
+	Gt
+	Gte
+	Lt
+	Lte
+)
+
+// Direction is the sort order of Query.OrderBy.
+type Direction int
+
+const (
+	Asc Direction = iota
+	Desc
+)
+
+// Query selects, sorts and pages objects by their fields. Start one with Bucket.Query.
+// Fields are named by their Go name or JSON name, with dots for nested structs, e.g. "Address.City".
+// Numbers compare by value regardless of their type, strings by bytes and times chronologically.
+// A nil pointer field only matches Eq nil and Ne with any other value.
+//
+// If the first filter on a top-level field with a FieldIndex uses Eq, Gt, Gte, Lt or Lte,
+// only the objects found through the index are decoded.
+// Filters that match the zero value scan instead, since objects without the field decode to it,
+// and so do filters on fields with a MarshalJSON or MarshalText method or the string option.
+type Query struct {
+	br      Bucket
+	filters []filter
+	orders  []order
+	limit   int
+	offset  int
+}
+
+type filter struct {
+	field string
+	op    Operator
+	value interface{}
+}
+
+type order struct {
+	field string
+	dir   Direction
+}
+
+// Query returns a Query over all objects in the bucket.
+func (br Bucket) Query() *Query {
+	return &Query{br: br, limit: -1}
+}
+
+// Where keeps only objects whose field compares to value with op.
+// Multiple filters must all match.
+func (q *Query) Where(field string, op Operator, value interface{}) *Query {
+	q.filters = append(q.filters, filter{field: field, op: op, value: value})
+	return q
+}
+
+// OrderBy sorts the results by field. Further calls break ties of earlier ones.
+// Without OrderBy, results are in key order.
+func (q *Query) OrderBy(field string, dir Direction) *Query {
+	q.orders = append(q.orders, order{field: field, dir: dir})
+	return q
+}
+
+// Limit returns at most n results.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Offset skips the first n results.
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
+
+// Into runs the query in a single read transaction and stores the results in dst,
+// which must be a pointer to a slice of structs or pointers to structs.
+// It returns ErrInvalidQuery for unknown fields and values that can't be compared with their field.
+func (q *Query) Into(dst interface{}) error {
+	sv := reflect.ValueOf(dst)
+	if sv.Kind() != reflect.Ptr || sv.IsNil() || sv.Elem().Kind() != reflect.Slice {
+		return ErrNonSliceArgument
+	}
+
+	elemType := sv.Elem().Type().Elem()
+	objType := elemType
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	if objType.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %s is not a struct", ErrInvalidQuery, objType)
+	}
+
+	if q.offset < 0 {
+		return fmt.Errorf("%w: negative offset", ErrInvalidQuery)
+	}
+
+	filters, err := q.resolveFilters(objType)
+	if err != nil {
+		return err
+	}
+
+	orders, err := q.resolveOrders(objType)
+	if err != nil {
+		return err
+	}
+
+	results := reflect.MakeSlice(sv.Elem().Type(), 0, 0)
+	err = q.br.View(func(r Reader) error {
+		visit := func(ptr interface{}) (stop bool) {
+			obj := reflect.ValueOf(ptr)
+			for _, f := range filters {
+				if !f.match(obj.Elem()) {
+					return false
+				}
+			}
+
+			if elemType.Kind() != reflect.Ptr {
+				obj = obj.Elem()
+			}
+			results = reflect.Append(results, obj)
+
+			return len(orders) == 0 && q.limit >= 0 && results.Len() >= q.offset+q.limit
+		}
+
+		return q.run(r, filters, reflect.New(objType).Interface(), visit)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(orders) > 0 {
+		sort.SliceStable(results.Interface(), func(i, j int) bool {
+			a, b := results.Index(i), results.Index(j)
+			for _, o := range orders {
+				c := o.compare(a, b)
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	}
+
+	start, end := q.offset, results.Len()
+	if start > end {
+		start = end
+	}
+	if q.limit >= 0 && start+q.limit < end {
+		end = start + q.limit
+	}
+
+	sv.Elem().Set(results.Slice(start, end))
+	return nil
+}
+
+// run passes the candidates for filters to visit in key order, until visit returns true.
+func (q *Query) run(r Reader, filters []resolvedFilter, dst interface{}, visit func(ptr interface{}) (stop bool)) error {
+	keys, ok, err := q.indexKeys(r, filters)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return r.scan("Query", bounds{}, scanOptions{}, dst, func(_ []byte, ptr interface{}) (bool, error) {
+			return visit(ptr), nil
+		})
+	}
+
+	b, err := r.bucket()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		data := b.Get(key)
+		if data == nil {
+			continue
+		}
+
+		ptr, err := r.br.decodeEach("Query", key, data, dst)
+		if err != nil {
+			return err
+		}
+		if ptr == nil {
+			continue
+		}
+
+		if visit(ptr) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// indexKeys returns the sorted primary keys of the candidates for the first filter that can use a FieldIndex.
+// It returns false if no filter can.
+func (q *Query) indexKeys(r Reader, filters []resolvedFilter) ([][]byte, bool, error) {
+	for _, f := range filters {
+		idx := q.br.fieldIndex(f.path.jsonName)
+		if idx == nil {
+			continue
+		}
+
+		bd, ok := f.indexBounds()
+		if !ok {
+			continue
+		}
+
+		ib, err := r.indexBucket(idx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var keys [][]byte
+		c := ib.Cursor()
+		for k, _ := bd.first(c); k != nil && bd.contains(k); k, _ = c.Next() {
+			keys = append(keys, clone(indexEntryKey(k)))
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+
+		return keys, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// fieldIndex returns the FieldIndex on a top-level JSON field, if any.
+func (br Bucket) fieldIndex(jsonName string) Index {
+	if jsonName == "" {
+		return nil
+	}
+
+	for _, idx := range br.indexes {
+		if fi, ok := idx.(*fieldIndex); ok && fi.field == jsonName {
+			return idx
+		}
+	}
+
+	return nil
+}
+
+type resolvedFilter struct {
+	path  fieldPath
+	op    Operator
+	value reflect.Value // invalid for nil
+}
+
+type resolvedOrder struct {
+	path fieldPath
+	dir  Direction
+}
+
+func (q *Query) resolveFilters(t reflect.Type) ([]resolvedFilter, error) {
+	var filters []resolvedFilter
+	for _, f := range q.filters {
+		path, err := resolveField(t, f.field)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.op < Eq || f.op > Lte {
+			return nil, fmt.Errorf("%w: unknown operator %d", ErrInvalidQuery, f.op)
+		}
+
+		value := reflect.ValueOf(f.value)
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Ptr {
+			value = reflect.Value{}
+		}
+
+		if !value.IsValid() {
+			if f.op != Eq && f.op != Ne {
+				return nil, fmt.Errorf("%w: %s can only be compared with nil using Eq or Ne", ErrInvalidQuery, f.field)
+			}
+		} else if _, ok := compareValues(reflect.Zero(path.typ), value); !ok {
+			return nil, fmt.Errorf("%w: cannot compare %s (%s) with %s", ErrInvalidQuery, f.field, path.typ, value.Type())
+		}
+
+		filters = append(filters, resolvedFilter{path: path, op: f.op, value: value})
+	}
+
+	return filters, nil
+}
+
+func (q *Query) resolveOrders(t reflect.Type) ([]resolvedOrder, error) {
+	var orders []resolvedOrder
+	for _, o := range q.orders {
+		path, err := resolveField(t, o.field)
+		if err != nil {
+			return nil, err
+		}
+
+		zero := reflect.Zero(path.typ)
+		if _, ok := compareValues(zero, zero); !ok {
+			return nil, fmt.Errorf("%w: cannot order by %s (%s)", ErrInvalidQuery, o.field, path.typ)
+		}
+
+		orders = append(orders, resolvedOrder{path: path, dir: o.dir})
+	}
+
+	return orders, nil
+}
+
+func (f resolvedFilter) match(obj reflect.Value) bool {
+	v, ok := f.path.get(obj)
+	if !ok || !f.value.IsValid() {
+		bothNull := ok == f.value.IsValid()
+		switch f.op {
+		case Eq:
+			return bothNull
+		case Ne:
+			return !bothNull
+		}
+		return false
+	}
+
+	c, _ := compareValues(v, f.value)
+	return f.holds(c)
+}
+
+// holds reports whether a comparison result c satisfies the operator of f.
+func (f resolvedFilter) holds(c int) bool {
+	switch f.op {
+	case Eq:
+		return c == 0
+	case Ne:
+		return c != 0
+	case Gt:
+		return c > 0
+	case Gte:
+		return c >= 0
+	case Lt:
+		return c < 0
+	case Lte:
+		return c <= 0
+	}
+
+	return false
+}
+
+// indexBounds returns the range of index entries that contains every match of f.
+// It returns false if the index may miss matches: objects without the field decode to the zero value,
+// so filters matching it need a scan, just like fields that aren't stored as their Go value.
+// Numbers are indexed as float64, so their bounds are inclusive to allow for rounding.
+func (f resolvedFilter) indexBounds() (bounds, bool) {
+	if !f.value.IsValid() || f.op == Ne || f.path.custom {
+		return bounds{}, false
+	}
+
+	if c, _ := compareValues(reflect.Zero(f.path.typ), f.value); !f.path.nullable && f.holds(c) {
+		return bounds{}, false
+	}
+
+	var encoded []byte
+	switch k := f.path.typ.Kind(); {
+	case k == reflect.String:
+		encoded, _ = encodeIndexValue(f.value.String())
+	case k == reflect.Bool && f.op == Eq:
+		encoded, _ = encodeIndexValue(f.value.Bool())
+	case isNumber(k):
+		n, ok := storedNumber(f.value, k)
+		if !ok {
+			return bounds{}, false
+		}
+		encoded = encodeIndexNumber(n)
+	default:
+		return bounds{}, false
+	}
+
+	typeStart := []byte{encoded[0]}
+	typeEnd := prefixEnd(typeStart)
+	valueStart := escapeIndexValue(encoded)
+	valueEnd := prefixEnd(indexPrefix(encoded))
+
+	op := f.op
+	if encoded[0] == indexNumber && op == Gt {
+		op = Gte
+	}
+	if encoded[0] == indexNumber && op == Lt {
+		op = Lte
+	}
+
+	switch op {
+	case Eq:
+		return prefixBounds(indexPrefix(encoded)), true
+	case Gt:
+		return bounds{from: valueEnd, to: typeEnd}, true
+	case Gte:
+		return bounds{from: valueStart, to: typeEnd}, true
+	case Lt:
+		return bounds{from: typeStart, to: valueStart}, true
+	case Lte:
+		return bounds{from: typeStart, to: valueEnd}, true
+	}
+
+	return bounds{}, false
+}
+
+// storedNumber converts v to the number a field of kind k holding v has in the index.
+// float32 fields are stored as the shortest decimal that identifies them, which differs from their float64 value.
+func storedNumber(v reflect.Value, k reflect.Kind) (float64, bool) {
+	n := toFloat(v)
+	if math.IsNaN(n) {
+		return 0, false
+	}
+
+	if k != reflect.Float32 {
+		return n, true
+	}
+
+	f := float64(float32(n))
+	if math.IsInf(f, 0) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+	return n, err == nil
+}
+
+// compare compares the field of two query results. Null sorts before any value.
+func (o resolvedOrder) compare(a, b reflect.Value) int {
+	va, oka := o.path.get(a)
+	vb, okb := o.path.get(b)
+
+	var c int
+	switch {
+	case !oka && !okb:
+		c = 0
+	case !oka:
+		c = -1
+	case !okb:
+		c = 1
+	default:
+		c, _ = compareValues(va, vb)
+	}
+
+	if o.dir == Desc {
+		return -c
+	}
+
+	return c
+}
+
+// fieldPath locates a field in a struct.
+type fieldPath struct {
+	index    [][]int      // field indexes per segment, including embedded structs
+	typ      reflect.Type // the field type, with pointers removed
+	jsonName string       // the JSON name of a top-level field
+	nullable bool         // the field is a pointer, so objects without it match no value
+	custom   bool         // the field isn't stored as its Go value, due to a JSON or text marshaler or the string option
+}
+
+// get returns the value of the field in v, which may be a struct or a pointer to one.
+// It returns false if a pointer on the way is nil.
+func (p fieldPath) get(v reflect.Value) (reflect.Value, bool) {
+	for _, index := range p.index {
+		for _, i := range index {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+			v = v.Field(i)
+		}
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	return v, true
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// resolveField finds a field by its Go or JSON name, with dots for nested structs.
+func resolveField(t reflect.Type, name string) (fieldPath, error) {
+	var p fieldPath
+	segments := strings.Split(name, ".")
+	for i, segment := range segments {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return fieldPath{}, fmt.Errorf("%w: unknown field %s", ErrInvalidQuery, name)
+		}
+		p.custom = p.custom || customJSON(t)
+
+		f, ok := findField(t, segment)
+		if !ok {
+			return fieldPath{}, fmt.Errorf("%w: unknown field %s", ErrInvalidQuery, name)
+		}
+
+		jsonName, asString := jsonTag(f)
+		if len(segments) == 1 {
+			p.jsonName = jsonName
+		}
+		if i == len(segments)-1 {
+			p.nullable = f.Type.Kind() == reflect.Ptr
+			p.custom = p.custom || asString
+		}
+
+		p.index = append(p.index, f.Index)
+		t = f.Type
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	p.typ = t
+	p.custom = p.custom || customJSON(t)
+
+	return p, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// customJSON reports whether values of type t may be encoded by their own MarshalJSON or MarshalText method.
+func customJSON(t reflect.Type) bool {
+	for _, t := range []reflect.Type{t, reflect.PtrTo(t)} {
+		if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findField finds an exported field by its Go or JSON name, including fields of embedded structs.
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _ := jsonTag(f)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			embedded = append(embedded, f)
+			continue
+		}
+		if f.PkgPath != "" || jsonName == "-" {
+			continue
+		}
+
+		if f.Name == name || jsonName == name {
+			return f, true
+		}
+	}
+
+	for _, e := range embedded {
+		t := e.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+
+		if inner, ok := findField(t, name); ok {
+			inner.Index = append(append([]int{}, e.Index...), inner.Index...)
+			return inner, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// jsonTag returns the name a field has in JSON and whether it is stored as a string by the string option.
+func jsonTag(f reflect.StructField) (name string, asString bool) {
+	tag := f.Tag.Get("json")
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+
+	for _, opt := range parts[1:] {
+		if opt == "string" {
+			asString = true
+		}
+	}
+
+	return name, asString
+}
+
+// compareValues compares two values of comparable kinds.
+// It returns false if they can't be compared.
+func compareValues(a, b reflect.Value) (int, bool) {
+	switch {
+	case a.Type() == timeType || b.Type() == timeType:
+		if a.Type() != b.Type() {
+			return 0, false
+		}
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+		return 0, true
+
+	case isInt(a.Kind()) && isInt(b.Kind()):
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int()), true
+
+	case isUint(a.Kind()) && isUint(b.Kind()):
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint()), true
+
+	case isNumber(a.Kind()) && isNumber(b.Kind()):
+		fa, fb := toFloat(a), toFloat(b)
+		return compareOrdered(fa < fb, fa > fb), true
+
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), true
+
+	case a.Kind() == reflect.Bool && b.Kind() == reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool()), true
+	}
+
+	return 0, false
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+
+	return 0
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isNumber(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case isInt(v.Kind()):
+		return float64(v.Int())
+	case isUint(v.Kind()):
+		return float64(v.Uint())
+	}
+
+	return v.Float()
+}
@@ -0,0 +1,223 @@
+package bbucket
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+type ticket struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Age     int       `json:"age"`
+	Created time.Time `json:"created"`
+	Owner   *string   `json:"owner"`
+	Meta    struct {
+		Priority uint8 `json:"prio"`
+	} `json:"meta"`
+	Score float32 `json:"score"`
+	Level level   `json:"level"`
+	Code  int     `json:"code,string"`
+}
+
+// level is stored as a quoted number, so a FieldIndex on it holds strings.
+type level int
+
+func (l level) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.Itoa(int(l)))), nil
+}
+
+func (l *level) UnmarshalJSON(data []byte) error {
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return err
+	}
+
+	n, err := strconv.Atoi(s)
+	*l = level(n)
+	return err
+}
+
+var day = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testTickets() []ticket {
+	alice := `alice`
+	tickets := []ticket{
+		{ID: `1`, Status: `open`, Age: 20, Created: day.Add(3 * time.Hour)},
+		{ID: `2`, Status: `closed`, Age: 35, Created: day.Add(1 * time.Hour), Owner: &alice},
+		{ID: `3`, Status: `open`, Age: 40, Created: day.Add(2 * time.Hour)},
+		{ID: `4`, Status: `open`, Age: 31, Created: day.Add(4 * time.Hour), Owner: &alice},
+		{ID: `5`, Status: `open`, Age: 30, Created: day},
+	}
+	scores := []float32{0.1, 0.2, 0.3, 0.1, 0.7}
+	for i := range tickets {
+		tickets[i].Meta.Priority = uint8(i % 2)
+		tickets[i].Score = scores[i]
+		tickets[i].Level = level(i % 3)
+		tickets[i].Code = 100 + i
+	}
+
+	return tickets
+}
+
+func getTicketRepo(opts ...Option) Bucket {
+	br := getTestRepo()
+	_ = br.DB.Update(func(tx *bbolt.Tx) error {
+		_ = tx.DeleteBucket(br.Bucket)
+		_, err := tx.CreateBucket(br.Bucket)
+		return err
+	})
+
+	br, err := Open(br.DB, br.Bucket, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	err = br.CreateAll(testTickets(), func(obj interface{}) ([]byte, error) {
+		return []byte(obj.(ticket).ID), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return br
+}
+
+func ticketIDs(tickets []ticket) []string {
+	ids := []string{}
+	for _, t := range tickets {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestQuery(t *testing.T) {
+	indexed := getTicketRepo(WithIndex(FieldIndex(`status`)), WithIndex(FieldIndex(`age`)),
+		WithIndex(FieldIndex(`score`)), WithIndex(FieldIndex(`level`)), WithIndex(FieldIndex(`code`)))
+	defer indexed.Close()
+	plain := Bucket{DB: indexed.DB, Bucket: indexed.Bucket}
+
+	// an object stored before most fields existed
+	err := indexed.Create([]byte(`6`), struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}{`6`, `new`})
+	if err != nil {
+		panic(err)
+	}
+
+	cases := []struct {
+		name     string
+		query    func(q *Query) *Query
+		expected []string
+	}{
+		{`all`, func(q *Query) *Query { return q }, []string{`1`, `2`, `3`, `4`, `5`, `6`}},
+		{`eq`, func(q *Query) *Query { return q.Where(`Status`, Eq, `open`) }, []string{`1`, `3`, `4`, `5`}},
+		{`ne`, func(q *Query) *Query { return q.Where(`status`, Ne, `open`) }, []string{`2`, `6`}},
+		{`gt`, func(q *Query) *Query { return q.Where(`Age`, Gt, 30) }, []string{`2`, `3`, `4`}},
+		{`gte`, func(q *Query) *Query { return q.Where(`Age`, Gte, 30.0) }, []string{`2`, `3`, `4`, `5`}},
+		{`lt`, func(q *Query) *Query { return q.Where(`Age`, Lt, uint(31)) }, []string{`1`, `5`, `6`}},
+		{`lte`, func(q *Query) *Query { return q.Where(`Age`, Lte, 31) }, []string{`1`, `4`, `5`, `6`}},
+		{`missing field`, func(q *Query) *Query { return q.Where(`Age`, Eq, 0) }, []string{`6`}},
+		{`float32 eq`, func(q *Query) *Query { return q.Where(`Score`, Eq, float32(0.1)) }, []string{`1`, `4`}},
+		{`float32 gte`, func(q *Query) *Query { return q.Where(`Score`, Gte, float32(0.3)) }, []string{`3`, `5`}},
+		{`float32 lte`, func(q *Query) *Query { return q.Where(`Score`, Lte, float32(0.2)) }, []string{`1`, `2`, `4`, `6`}},
+		{`float32 gt`, func(q *Query) *Query { return q.Where(`Score`, Gt, float32(0.1)) }, []string{`2`, `3`, `5`}},
+		{`float32 with float64`, func(q *Query) *Query { return q.Where(`Score`, Eq, 0.1) }, []string{}},
+		{`marshaler`, func(q *Query) *Query { return q.Where(`Level`, Eq, 1) }, []string{`2`, `5`}},
+		{`marshaler range`, func(q *Query) *Query { return q.Where(`Level`, Gte, 1) }, []string{`2`, `3`, `5`}},
+		{`string option`, func(q *Query) *Query { return q.Where(`Code`, Gt, 102) }, []string{`4`, `5`}},
+		{`combined`, func(q *Query) *Query {
+			return q.Where(`Status`, Eq, `open`).Where(`Age`, Gt, 30)
+		}, []string{`3`, `4`}},
+		{`time`, func(q *Query) *Query { return q.Where(`Created`, Lt, day.Add(2*time.Hour)) }, []string{`2`, `5`, `6`}},
+		{`nested`, func(q *Query) *Query { return q.Where(`Meta.prio`, Eq, 1) }, []string{`2`, `4`}},
+		{`nil`, func(q *Query) *Query { return q.Where(`Owner`, Eq, nil) }, []string{`1`, `3`, `5`, `6`}},
+		{`not nil`, func(q *Query) *Query { return q.Where(`Owner`, Ne, nil) }, []string{`2`, `4`}},
+		{`pointer`, func(q *Query) *Query { return q.Where(`Owner`, Eq, `alice`) }, []string{`2`, `4`}},
+		{`order`, func(q *Query) *Query { return q.OrderBy(`Created`, Desc) }, []string{`4`, `1`, `3`, `2`, `5`, `6`}},
+		{`order ties`, func(q *Query) *Query {
+			return q.OrderBy(`Status`, Asc).OrderBy(`Age`, Desc)
+		}, []string{`2`, `6`, `3`, `4`, `5`, `1`}},
+		{`order nil first`, func(q *Query) *Query { return q.OrderBy(`Owner`, Asc) }, []string{`1`, `3`, `5`, `6`, `2`, `4`}},
+		{`limit and offset`, func(q *Query) *Query {
+			return q.Where(`Status`, Eq, `open`).OrderBy(`Age`, Asc).Limit(2).Offset(1)
+		}, []string{`5`, `4`}},
+		{`limit without order`, func(q *Query) *Query { return q.Limit(2).Offset(2) }, []string{`3`, `4`}},
+		{`offset past end`, func(q *Query) *Query { return q.Offset(10) }, []string{}},
+		{`no match`, func(q *Query) *Query { return q.Where(`Status`, Eq, `pending`) }, []string{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			for _, br := range []Bucket{indexed, plain} {
+				var actual []ticket
+				err := c.query(br.Query()).Into(&actual)
+				assert.NoError(err)
+				assert.Cmp(c.expected, ticketIDs(actual))
+			}
+		})
+	}
+
+	t.Run(`pointers`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var actual []*ticket
+		err := indexed.Query().Where(`Age`, Eq, 40).Into(&actual)
+		assert.NoError(err)
+		assert.Eq(1, len(actual))
+		assert.Eq(`3`, actual[0].ID)
+	})
+
+	t.Run(`invalid`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var actual []ticket
+		err := indexed.Query().Where(`Missing`, Eq, 1).Into(&actual)
+		assert.Eq(true, errors.Is(err, ErrInvalidQuery))
+
+		err = indexed.Query().Where(`Age`, Eq, `old`).Into(&actual)
+		assert.Eq(true, errors.Is(err, ErrInvalidQuery))
+
+		err = indexed.Query().Where(`Age`, Gt, nil).Into(&actual)
+		assert.Eq(true, errors.Is(err, ErrInvalidQuery))
+
+		err = indexed.Query().OrderBy(`Meta`, Asc).Into(&actual)
+		assert.Eq(true, errors.Is(err, ErrInvalidQuery))
+
+		err = indexed.Query().Into(actual)
+		assert.Eq(ErrNonSliceArgument, err)
+
+		var ints []int
+		err = indexed.Query().Into(&ints)
+		assert.Eq(true, errors.Is(err, ErrInvalidQuery))
+	})
+}
+
+func TestQueryUsesIndex(t *testing.T) {
+	assert := assert.New(t)
+	br := getTicketRepo(WithIndex(FieldIndex(`status`)))
+	defer br.Close()
+
+	// an object stored around the index is only found by scanning
+	assert.NoError(br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.Put([]byte(`6`), []byte(`{"id":"6","status":"open"}`))
+	}))
+
+	var actual []ticket
+	assert.NoError(br.Query().Where(`Status`, Eq, `open`).Into(&actual))
+	assert.Cmp([]string{`1`, `3`, `4`, `5`}, ticketIDs(actual))
+
+	assert.NoError(br.Query().Where(`Age`, Eq, 0).Into(&actual))
+	assert.Cmp([]string{`6`}, ticketIDs(actual))
+
+	assert.NoError(br.Reindex())
+	assert.NoError(br.Query().Where(`Status`, Eq, `open`).Into(&actual))
+	assert.Cmp([]string{`1`, `3`, `4`, `5`, `6`}, ticketIDs(actual))
+}
@@ -2,71 +2,113 @@ package bbucket
 
 import (
 	"encoding/json"
-
-	"go.etcd.io/bbolt"
+	"errors"
+	"reflect"
 )
 
 // Get scans a single object by key
 // If the key is unknown, it returns ErrObjectNotFound
 func (br Bucket) Get(key []byte, dst interface{}) error {
-	return br.BucketView(func(b *bbolt.Bucket) error {
-		data := b.Get(key)
-		if data == nil {
-			return ErrObjectNotFound
-		}
+	if br.cache != nil {
+		return br.cache.get(key, dst, func(ptr interface{}) error {
+			return br.get(key, ptr)
+		})
+	}
 
-		return json.Unmarshal(data, dst)
+	return br.get(key, dst)
+}
+
+func (br Bucket) get(key []byte, dst interface{}) error {
+	return br.View(func(r Reader) error {
+		return r.Get(key, dst)
 	})
 }
 
 // GetAll iterates over all objects in the bucket.
-// f receives a pointer to an object.
+// f receives a pointer to a newly allocated object of the same type as dst,
+// so the pointer can safely be retained after f returns.
 // Add the object to a slice defined in outside scope.
+// Pass Reverse() to iterate in descending key order.
 // Get your object of type T using: `*ptr.(*T)`
-func (br Bucket) GetAll(dst interface{}, f func(ptr interface{}) error) error {
+func (br Bucket) GetAll(dst interface{}, f func(ptr interface{}) error, opts ...ScanOption) error {
 	if f == nil {
 		return ErrNilFuncPassed
 	}
 
-	return br.BucketView(func(b *bbolt.Bucket) error {
-		return b.ForEach(func(_, v []byte) error {
-			err := json.Unmarshal(v, dst)
-			if err != nil {
-				return err
-			}
-
-			return f(dst)
-		})
+	return br.View(func(r Reader) error {
+		return r.GetAll(dst, f, opts...)
 	})
 }
 
 // Find uses a cursor to iterate over all objects in the bucket.
 // It stops when f returns found = true or a non-nil error.
 // If it reaches the end, it returns ErrObjectNotFound
+// f receives a pointer to a newly allocated object for every record.
+// When found, the object is also copied into dst.
+// Pass Reverse() to search in descending key order.
+// Get your object of type T using: `*ptr.(*T)`
+func (br Bucket) Find(dst interface{}, f func(key []byte, ptr interface{}) (found bool, err error), opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	return br.View(func(r Reader) error {
+		return r.Find(dst, f, opts...)
+	})
+}
+
+// GetMany looks up multiple objects by key in a single transaction.
+// f is called for every key, with found = false and a nil ptr if the key is unknown.
+// Otherwise ptr points to a newly allocated object of the same type as dst.
+// If an object can't be decoded, GetMany fails, unless OnDecodeError is set:
+// then the error is reported and f is not called for that key.
+// Keys are visited in the given order, or in key order with SortKeys(),
+// which is faster for many keys. Reverse() reverses the order.
 // Get your object of type T using: `*ptr.(*T)`
-func (br Bucket) Find(dst interface{}, f func(key []byte, ptr interface{}) (found bool, err error)) error {
+func (br Bucket) GetMany(keys [][]byte, dst interface{}, f func(key []byte, ptr interface{}, found bool) error, opts ...ScanOption) error {
 	if f == nil {
 		return ErrNilFuncPassed
 	}
 
-	return br.BucketView(func(b *bbolt.Bucket) error {
-		c, found := b.Cursor(), false
-		for k, v := c.First(); !found; k, v = c.Next() {
-			if k == nil {
-				return ErrObjectNotFound
-			}
-
-			err := json.Unmarshal(v, dst)
-			if err != nil {
-				return err
-			}
-
-			found, err = f(k, dst)
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
+	return br.View(func(r Reader) error {
+		return r.GetMany(keys, dst, f, opts...)
 	})
 }
+
+// decodeEach decodes a record into a fresh copy of dst while scanning the bucket.
+// If decoding fails and an OnDecodeError handler is set, the error is reported
+// and a nil ptr is returned to skip the record.
+func (br Bucket) decodeEach(op string, key, data []byte, dst interface{}) (ptr interface{}, err error) {
+	ptr = fresh(dst)
+	err = br.decode(data, ptr)
+	if err == nil {
+		return ptr, nil
+	}
+
+	var invalid *json.InvalidUnmarshalError
+	if br.onDecodeError == nil || errors.As(err, &invalid) {
+		return nil, br.keyError(op, key, err)
+	}
+
+	br.onDecodeError(br.keyError(op, key, err))
+	return nil, nil
+}
+
+// zero sets the value dst points to to its zero value.
+func zero(dst interface{}) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Type().Elem()))
+	}
+}
+
+// fresh returns a pointer to a new zero value of the type dst points to.
+// Non-pointer destinations are returned as is, so that decoding reports the error.
+func fresh(dst interface{}) interface{} {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return dst
+	}
+
+	return reflect.New(v.Type().Elem()).Interface()
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
 )
 
 func TestGet(t *testing.T) {
@@ -29,7 +30,7 @@ func TestGet(t *testing.T) {
 		var actual testStruct
 		err := br.Get([]byte("blablabla"), &actual)
 
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
 	})
 }
 
@@ -51,6 +52,32 @@ func TestGetAll(t *testing.T) {
 		assert.Cmp(expected, actual)
 	})
 
+	t.Run(`fresh object per record`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+			return b.Put([]byte(`ZZZ`), []byte(`{"a":"ZZZ"}`))
+		})
+		assert.NoError(err)
+		defer func() {
+			_ = br.Delete([]byte(`ZZZ`))
+		}()
+
+		expected := append(append([]testStruct{}, testData...), testStruct{ID: `ZZZ`})
+		var ptrs []*testStruct
+		err = br.GetAll(&testStruct{}, func(obj interface{}) error {
+			ptrs = append(ptrs, obj.(*testStruct))
+			return nil
+		})
+		assert.NoError(err)
+
+		var actual []testStruct
+		for _, ptr := range ptrs {
+			actual = append(actual, *ptr)
+		}
+		assert.Cmp(expected, actual)
+	})
+
 	t.Run(`nil function`, func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -86,6 +113,17 @@ func TestFind(t *testing.T) {
 		assert.Eq(obj, testStruct2)
 	})
 
+	t.Run(`found object is copied into dst`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var dst testStruct
+		err := br.Find(&dst, func(key []byte, ptr interface{}) (found bool, err error) {
+			return ptr.(*testStruct).ID == testStruct2.ID, nil
+		})
+		assert.NoError(err)
+		assert.Eq(testStruct2, dst)
+	})
+
 	t.Run(`find returns ErrObjectNotFound`, func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -129,3 +167,81 @@ func TestFind(t *testing.T) {
 		assert.Error(err)
 	})
 }
+
+func TestGetMany(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	keys := [][]byte{testStruct3.Key(), testStruct4.Key(), testStruct1.Key()}
+	getMany := func(opts ...ScanOption) ([]string, []testStruct, error) {
+		var visited []string
+		var found []testStruct
+		err := br.GetMany(keys, &testStruct{}, func(key []byte, ptr interface{}, ok bool) error {
+			visited = append(visited, string(key))
+			if ok {
+				found = append(found, *ptr.(*testStruct))
+			}
+			return nil
+		}, opts...)
+		return visited, found, err
+	}
+
+	t.Run(`given order`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		visited, found, err := getMany()
+		assert.NoError(err)
+		assert.Cmp([]string{`CDE`, `XYZ`, `ABC`}, visited)
+		assert.Cmp([]testStruct{testStruct3, testStruct1}, found)
+	})
+
+	t.Run(`sorted`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		visited, found, err := getMany(SortKeys())
+		assert.NoError(err)
+		assert.Cmp([]string{`ABC`, `CDE`, `XYZ`}, visited)
+		assert.Cmp([]testStruct{testStruct1, testStruct3}, found)
+
+		visited, _, err = getMany(SortKeys(), Reverse())
+		assert.NoError(err)
+		assert.Cmp([]string{`XYZ`, `CDE`, `ABC`}, visited)
+
+		// the caller's slice is not sorted
+		assert.Eq(`CDE`, string(keys[0]))
+	})
+
+	t.Run(`error`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.GetMany(keys, &testStruct{}, func([]byte, interface{}, bool) error {
+			return ErrNoKey
+		})
+		assert.Eq(ErrNoKey, err)
+
+		err = br.GetMany(keys, &testStruct{}, nil)
+		assert.Eq(ErrNilFuncPassed, err)
+	})
+
+	t.Run(`lenient`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(putCorrupt(br, `XYZ`))
+		_, _, err := getMany()
+		var keyErr *KeyError
+		assert.Eq(true, errors.As(err, &keyErr))
+		assert.Eq(`XYZ`, string(keyErr.Key))
+
+		// corrupt objects are reported and skipped, so f isn't called for their keys
+		var reported []error
+		OnDecodeError(func(err error) {
+			reported = append(reported, err)
+		})(&br)
+		visited, found, err := getMany()
+		assert.NoError(err)
+		assert.Cmp([]string{`CDE`, `ABC`}, visited)
+		assert.Cmp([]testStruct{testStruct3, testStruct1}, found)
+		assert.Eq(1, len(reported))
+		assert.Eq(`XYZ`, string(reported[0].(*KeyError).Key))
+	})
+}
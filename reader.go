@@ -0,0 +1,237 @@
+package bbucket
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+// Reader reads from a bucket within a single read transaction,
+// so that several reads see the same state of the DB.
+// It does not use the Cache, since cached objects may be newer than the transaction.
+// A Reader must not be used after the function passed to View returns.
+type Reader struct {
+	br  Bucket
+	tx  *bbolt.Tx
+	err error
+}
+
+// View calls f with a Reader in a single read transaction.
+// Use Reader.With to read other buckets on the same DB in the same transaction.
+func (br Bucket) View(f func(r Reader) error) error {
+	return br.DB.View(func(tx *bbolt.Tx) error {
+		return f(Reader{br: br, tx: tx})
+	})
+}
+
+// With returns a Reader for another bucket in the same transaction.
+// The bucket must be on the same DB, otherwise its methods return ErrOtherDB
+func (r Reader) With(other Bucket) Reader {
+	if other.DB != r.br.DB {
+		return Reader{br: other, tx: r.tx, err: ErrOtherDB}
+	}
+
+	return Reader{br: other, tx: r.tx}
+}
+
+func (r Reader) bucket() (*bbolt.Bucket, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	b := r.tx.Bucket(r.br.Bucket)
+	if b == nil {
+		return nil, ErrBucketNotFound
+	}
+
+	return b, nil
+}
+
+// Get scans a single object by key
+// If the key is unknown, it returns ErrObjectNotFound
+func (r Reader) Get(key []byte, dst interface{}) error {
+	b, err := r.bucket()
+	if err != nil {
+		return err
+	}
+
+	data := b.Get(key)
+	if data == nil {
+		return r.br.keyError("Get", key, ErrObjectNotFound)
+	}
+
+	err = r.br.decode(data, dst)
+	if err != nil {
+		return r.br.keyError("Get", key, err)
+	}
+
+	return nil
+}
+
+// Exists reports whether an object is stored at key.
+func (r Reader) Exists(key []byte) (bool, error) {
+	b, err := r.bucket()
+	if err != nil {
+		return false, err
+	}
+
+	return b.Get(key) != nil, nil
+}
+
+// Count returns the number of objects in the bucket.
+func (r Reader) Count() (int, error) {
+	b, err := r.bucket()
+	if err != nil {
+		return 0, err
+	}
+
+	return b.Stats().KeyN, nil
+}
+
+// GetAll works like Bucket.GetAll within the transaction.
+func (r Reader) GetAll(dst interface{}, f func(ptr interface{}) error, opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	return r.scan("GetAll", bounds{}, newScanOptions(opts), dst, func(_ []byte, ptr interface{}) (bool, error) {
+		return false, f(ptr)
+	})
+}
+
+// Find works like Bucket.Find within the transaction.
+func (r Reader) Find(dst interface{}, f func(key []byte, ptr interface{}) (found bool, err error), opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	found := false
+	err := r.scan("Find", bounds{}, newScanOptions(opts), dst, func(k []byte, ptr interface{}) (bool, error) {
+		var err error
+		found, err = f(k, ptr)
+		if found && ptr != dst {
+			reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(ptr).Elem())
+		}
+
+		return found, err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return ErrObjectNotFound
+	}
+
+	return nil
+}
+
+// GetMany works like Bucket.GetMany within the transaction.
+func (r Reader) GetMany(keys [][]byte, dst interface{}, f func(key []byte, ptr interface{}, found bool) error, opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	b, err := r.bucket()
+	if err != nil {
+		return err
+	}
+
+	so := newScanOptions(opts)
+	keys = append([][]byte{}, keys...)
+	if so.sortKeys {
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+	}
+	if so.reverse {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	for _, key := range keys {
+		data := b.Get(key)
+		if data == nil {
+			err := f(key, nil, false)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		ptr, err := r.br.decodeEach("GetMany", key, data, dst)
+		if err != nil {
+			return err
+		}
+		if ptr == nil {
+			continue
+		}
+
+		err = f(key, ptr, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Range calls f for every object with from <= key < to, in key order or in reverse with Reverse().
+// A nil from or to leaves that side of the range open.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// key is only valid during the transaction.
+func (r Reader) Range(from, to []byte, dst interface{}, f func(key []byte, ptr interface{}) error, opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	return r.scan("Range", bounds{from: from, to: to}, newScanOptions(opts), dst, func(k []byte, ptr interface{}) (bool, error) {
+		return false, f(k, ptr)
+	})
+}
+
+// Prefix calls f for every object whose key starts with p, in key order or in reverse with Reverse().
+// key is only valid during the transaction.
+func (r Reader) Prefix(p []byte, dst interface{}, f func(key []byte, ptr interface{}) error, opts ...ScanOption) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	return r.scan("Prefix", prefixBounds(p), newScanOptions(opts), dst, func(k []byte, ptr interface{}) (bool, error) {
+		return false, f(k, ptr)
+	})
+}
+
+// scan decodes every object within bd and passes it to f until f returns stop = true or an error.
+func (r Reader) scan(op string, bd bounds, so scanOptions, dst interface{}, f func(key []byte, ptr interface{}) (stop bool, err error)) error {
+	b, err := r.bucket()
+	if err != nil {
+		return err
+	}
+
+	c := b.Cursor()
+	start, next := bd.first, c.Next
+	if so.reverse {
+		start, next = bd.last, c.Prev
+	}
+
+	for k, v := start(c); k != nil && bd.contains(k); k, v = next() {
+		ptr, err := r.br.decodeEach(op, k, v, dst)
+		if err != nil {
+			return err
+		}
+		if ptr == nil {
+			continue
+		}
+
+		stop, err := f(k, ptr)
+		if err != nil || stop {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package bbucket
+
+import (
+	"errors"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestView(t *testing.T) {
+	t.Run(`get`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.View(func(r Reader) error {
+			var obj testStruct
+			assert.NoError(r.Get(testStruct1.Key(), &obj))
+			assert.Eq(testStruct1, obj)
+
+			exists, err := r.Exists(testStruct1.Key())
+			assert.NoError(err)
+			assert.Eq(true, exists)
+
+			n, err := r.Count()
+			assert.NoError(err)
+			assert.Eq(3, n)
+
+			return nil
+		})
+		assert.NoError(err)
+
+		assert.NoError(br.Delete(testStruct1.Key()))
+		err = br.View(func(r Reader) error {
+			exists, err := r.Exists(testStruct1.Key())
+			assert.NoError(err)
+			assert.Eq(false, exists)
+
+			err = r.Get(testStruct1.Key(), &testStruct{})
+			assert.Eq(true, errors.Is(err, ErrObjectNotFound))
+			return nil
+		})
+		assert.NoError(err)
+	})
+
+	t.Run(`scans`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.View(func(r Reader) error {
+			var all []testStruct
+			assert.NoError(r.GetAll(&testStruct{}, func(ptr interface{}) error {
+				all = append(all, *ptr.(*testStruct))
+				return nil
+			}))
+			assert.Cmp(testData, all)
+
+			var found testStruct
+			assert.NoError(r.Find(&found, func(_ []byte, ptr interface{}) (bool, error) {
+				return ptr.(*testStruct).Data > 200, nil
+			}))
+			assert.Eq(testStruct2, found)
+
+			err := r.Find(&found, func(_ []byte, ptr interface{}) (bool, error) {
+				return false, nil
+			})
+			assert.Eq(ErrObjectNotFound, err)
+
+			var keys []string
+			assert.NoError(r.Range([]byte(`B`), []byte(`CDE`), &testStruct{}, func(key []byte, ptr interface{}) error {
+				keys = append(keys, string(key))
+				return nil
+			}))
+			assert.Cmp([]string{`BCD`}, keys)
+
+			keys = nil
+			assert.NoError(r.Prefix([]byte(`C`), &testStruct{}, func(key []byte, ptr interface{}) error {
+				keys = append(keys, string(key))
+				return nil
+			}))
+			assert.Cmp([]string{`CDE`}, keys)
+
+			assert.Eq(ErrNilFuncPassed, r.Range(nil, nil, &testStruct{}, nil))
+			return nil
+		})
+		assert.NoError(err)
+	})
+
+	t.Run(`with`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		other, err := Open(br.DB, []byte(`other`))
+		assert.NoError(err)
+		assert.NoError(other.Create(testStruct4.Key(), testStruct4))
+		defer func() {
+			_ = br.DB.Update(func(tx *bbolt.Tx) error {
+				return tx.DeleteBucket(other.Bucket)
+			})
+		}()
+
+		err = br.View(func(r Reader) error {
+			var obj testStruct
+			assert.NoError(r.With(other).Get(testStruct4.Key(), &obj))
+			assert.Eq(testStruct4, obj)
+
+			err := r.With(other).Get(testStruct1.Key(), &obj)
+			assert.Eq(true, errors.Is(err, ErrObjectNotFound))
+
+			_, err = r.With(Bucket{DB: br.DB, Bucket: []byte(`missing`)}).Count()
+			assert.Eq(ErrBucketNotFound, err)
+
+			_, err = r.With(Bucket{Bucket: br.Bucket}).Exists(testStruct1.Key())
+			assert.Eq(ErrOtherDB, err)
+			return nil
+		})
+		assert.NoError(err)
+	})
+}
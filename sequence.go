@@ -0,0 +1,62 @@
+package bbucket
+
+import "go.etcd.io/bbolt"
+
+// NextSequence returns an autoincrementing integer for the bucket.
+// Panics if the sequence cannot be updated, use NextSequenceErr to handle the error instead.
+func (br Bucket) NextSequence() int {
+	i, err := br.NextSequenceErr()
+	if err != nil {
+		panic(err)
+	}
+
+	return i
+}
+
+// NextSequenceErr returns an autoincrementing integer for the bucket.
+func (br Bucket) NextSequenceErr() (int, error) {
+	var i uint64
+	err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+		j, err := b.NextSequence()
+		i = j
+		return err
+	})
+
+	return int(i), err
+}
+
+// Sequence returns the current sequence of the bucket without incrementing it.
+func (br Bucket) Sequence() (int, error) {
+	var i uint64
+	err := br.BucketView(func(b *bbolt.Bucket) error {
+		i = b.Sequence()
+		return nil
+	})
+
+	return int(i), err
+}
+
+// SetSequence updates the sequence of the bucket.
+// The next call to NextSequence returns i + 1.
+func (br Bucket) SetSequence(i int) error {
+	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.SetSequence(uint64(i))
+	})
+}
+
+// ReserveSequence reserves a block of n sequence numbers in a single transaction.
+// It returns the first number of the block, the reserved numbers are first through first + n - 1.
+// If n is not positive, it returns ErrNonPositiveCount
+func (br Bucket) ReserveSequence(n int) (first int, err error) {
+	if n <= 0 {
+		return 0, ErrNonPositiveCount
+	}
+
+	err = br.BucketUpdate(func(b *bbolt.Bucket) error {
+		i := b.Sequence()
+		first = int(i) + 1
+		return b.SetSequence(i + uint64(n))
+	})
+
+	return first, err
+}
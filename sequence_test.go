@@ -0,0 +1,79 @@
+package bbucket
+
+import (
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestNextSequence(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	i := br.NextSequence()
+	assert.Eq(1, i)
+
+	i = br.NextSequence()
+	assert.Eq(2, i)
+}
+
+func TestNextSequenceErr(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+
+	i, err := br.NextSequenceErr()
+	assert.NoError(err)
+	assert.Eq(1, i)
+
+	br.Close()
+
+	_, err = br.NextSequenceErr()
+	assert.Eq(bbolt.ErrDatabaseNotOpen, err)
+}
+
+func TestSequence(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	i, err := br.Sequence()
+	assert.NoError(err)
+	assert.Eq(0, i)
+
+	err = br.SetSequence(41)
+	assert.NoError(err)
+
+	i, err = br.Sequence()
+	assert.NoError(err)
+	assert.Eq(41, i)
+
+	assert.Eq(42, br.NextSequence())
+}
+
+func TestReserveSequence(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	t.Run(`reserve block`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		first, err := br.ReserveSequence(10)
+		assert.NoError(err)
+		assert.Eq(1, first)
+
+		first, err = br.ReserveSequence(5)
+		assert.NoError(err)
+		assert.Eq(11, first)
+
+		assert.Eq(16, br.NextSequence())
+	})
+
+	t.Run(`non-positive count`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := br.ReserveSequence(0)
+		assert.Eq(ErrNonPositiveCount, err)
+	})
+}
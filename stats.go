@@ -0,0 +1,94 @@
+package bbucket
+
+import "go.etcd.io/bbolt"
+
+// Stats describes the size and layout of a bucket.
+// ValueBytes is the total size of all stored values,
+// the other fields are the bbolt statistics of the bucket.
+type Stats struct {
+	bbolt.BucketStats
+	ValueBytes int
+}
+
+// Count returns the number of objects in the bucket without decoding them.
+func (br Bucket) Count() (int, error) {
+	var n int
+	err := br.View(func(r Reader) error {
+		var err error
+		n, err = r.Count()
+		return err
+	})
+
+	return n, err
+}
+
+// Exists reports whether an object is stored at key.
+func (br Bucket) Exists(key []byte) (bool, error) {
+	var exists bool
+	err := br.View(func(r Reader) error {
+		var err error
+		exists, err = r.Exists(key)
+		return err
+	})
+
+	return exists, err
+}
+
+// Keys returns the keys of all objects whose key starts with prefix, in order.
+// A nil prefix returns all keys.
+func (br Bucket) Keys(prefix []byte) ([][]byte, error) {
+	var keys [][]byte
+	err := br.View(func(r Reader) error {
+		var err error
+		keys, err = r.Keys(prefix)
+		return err
+	})
+
+	return keys, err
+}
+
+// Stats returns the statistics of the bucket.
+func (br Bucket) Stats() (Stats, error) {
+	var s Stats
+	err := br.View(func(r Reader) error {
+		var err error
+		s, err = r.Stats()
+		return err
+	})
+
+	return s, err
+}
+
+// Keys returns copies of the keys of all objects whose key starts with prefix, in order.
+// A nil prefix returns all keys.
+func (r Reader) Keys(prefix []byte) ([][]byte, error) {
+	b, err := r.bucket()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := [][]byte{}
+	bd := prefixBounds(prefix)
+	c := b.Cursor()
+	for k, _ := bd.first(c); k != nil && bd.contains(k); k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+
+	return keys, nil
+}
+
+// Stats returns the statistics of the bucket.
+func (r Reader) Stats() (Stats, error) {
+	b, err := r.bucket()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	s := Stats{BucketStats: b.Stats()}
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		s.ValueBytes += len(v)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,76 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+func TestCount(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	n, err := br.Count()
+	assert.NoError(err)
+	assert.Eq(3, n)
+
+	assert.NoError(putCorrupt(br, `corrupt`))
+	n, err = br.Count()
+	assert.NoError(err)
+	assert.Eq(4, n)
+
+	_, err = Bucket{DB: br.DB, Bucket: []byte(`missing`)}.Count()
+	assert.Eq(ErrBucketNotFound, err)
+}
+
+func TestExists(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	exists, err := br.Exists(testStruct1.Key())
+	assert.NoError(err)
+	assert.Eq(true, exists)
+
+	exists, err = br.Exists(testStruct4.Key())
+	assert.NoError(err)
+	assert.Eq(false, exists)
+}
+
+func TestKeys(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	keys, err := br.Keys(nil)
+	assert.NoError(err)
+	assert.Cmp([][]byte{[]byte(`ABC`), []byte(`BCD`), []byte(`CDE`)}, keys)
+
+	keys, err = br.Keys([]byte(`B`))
+	assert.NoError(err)
+	assert.Cmp([][]byte{[]byte(`BCD`)}, keys)
+
+	keys, err = br.Keys([]byte(`Z`))
+	assert.NoError(err)
+	assert.Eq(0, len(keys))
+}
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	size := 0
+	for _, obj := range testData {
+		data, _ := json.Marshal(obj)
+		size += len(data)
+	}
+
+	s, err := br.Stats()
+	assert.NoError(err)
+	assert.Eq(3, s.KeyN)
+	assert.Eq(size, s.ValueBytes)
+	assert.Eq(1, s.Depth)
+}
@@ -0,0 +1,131 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+var errTrailingData = errors.New("json: invalid data after top-level value")
+
+// Strict makes the bucket reject stored objects with fields that dst does not have.
+// Numbers decoded into interface{} values become json.Number instead of float64.
+// It applies to Get, GetAll, Find, Update, UpdateAll and iterators.
+func Strict() Option {
+	return func(br *Bucket) {
+		br.strict = true
+	}
+}
+
+// decode decodes a stored object into dst, honouring the Strict option.
+func (br Bucket) decode(data []byte, dst interface{}) error {
+	if !br.strict {
+		return json.Unmarshal(data, dst)
+	}
+
+	return decodeStrict(data, dst)
+}
+
+func decodeStrict(data []byte, dst interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	d.UseNumber()
+
+	err := d.Decode(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		return errTrailingData
+	}
+
+	return nil
+}
+
+// AuditResult lists the fields of a stored object that are unknown to the audited type.
+// Unknown fields of nested objects are listed as parent.child.
+type AuditResult struct {
+	Key    []byte
+	Fields []string
+}
+
+// Audit scans the bucket for objects with fields that dst does not have.
+// It returns a result for every such object, regardless of the Strict option.
+// Objects that are not JSON objects are skipped.
+func (br Bucket) Audit(dst interface{}) ([]AuditResult, error) {
+	var results []AuditResult
+	err := br.BucketView(func(b *bbolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			fields, err := unknownFields(v, dst)
+			if err != nil {
+				return br.keyError("Audit", k, err)
+			}
+
+			if len(fields) > 0 {
+				results = append(results, AuditResult{
+					Key:    append([]byte{}, k...),
+					Fields: fields,
+				})
+			}
+
+			return nil
+		})
+	})
+
+	return results, err
+}
+
+// unknownFields decodes every top-level field of data on its own into a fresh copy of dst,
+// so that other decode errors can't hide unknown fields.
+func unknownFields(data []byte, dst interface{}) ([]string, error) {
+	var doc map[string]json.RawMessage
+	if json.Unmarshal(data, &doc) != nil {
+		return nil, nil
+	}
+
+	var fields []string
+	for name, value := range doc {
+		// decoding null first tells unknown top-level fields apart from unknown nested fields
+		for i, v := range []json.RawMessage{json.RawMessage(`null`), value} {
+			field, err := json.Marshal(map[string]json.RawMessage{name: v})
+			if err != nil {
+				return nil, err
+			}
+
+			err = decodeStrict(field, fresh(dst))
+			var invalid *json.InvalidUnmarshalError
+			if errors.As(err, &invalid) {
+				return nil, err
+			}
+
+			if unknown, ok := unknownField(err); ok {
+				if i > 0 {
+					unknown = name + "." + unknown
+				}
+				fields = append(fields, unknown)
+				break
+			}
+		}
+	}
+
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// unknownField extracts the field name from a DisallowUnknownFields error.
+func unknownField(err error) (string, bool) {
+	const prefix = "json: unknown field "
+	if err == nil || !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+
+	name, err := strconv.Unquote(strings.TrimPrefix(err.Error(), prefix))
+	return name, err == nil
+}
@@ -0,0 +1,100 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+type nestedStruct struct {
+	Inner testStruct `json:"inner"`
+	Value int        `json:"value"`
+}
+
+func TestStrict(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+	Strict()(&br)
+
+	err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+		return b.Put([]byte(`ZZZ`), []byte(`{"a":"ZZZ","b":1,"c":2}`))
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	t.Run(`known fields`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var actual testStruct
+		err := br.Get(testStruct1.Key(), &actual)
+		assert.NoError(err)
+		assert.Eq(testStruct1, actual)
+	})
+
+	t.Run(`unknown field`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := br.Get([]byte(`ZZZ`), &testStruct{})
+		assert.Error(err)
+
+		err = br.GetAll(&testStruct{}, func(ptr interface{}) error { return nil })
+		assert.Error(err)
+
+		err = br.Update([]byte(`ZZZ`), &testStruct{}, func(ptr interface{}) (interface{}, error) {
+			return *ptr.(*testStruct), nil
+		})
+		assert.Error(err)
+	})
+
+	t.Run(`use number`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		var actual map[string]interface{}
+		err := br.Get(testStruct1.Key(), &actual)
+		assert.NoError(err)
+		assert.Eq(json.Number(`123`), actual[`b`])
+	})
+
+	t.Run(`trailing data`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := decodeStrict([]byte(`{"a":"x"} {}`), &testStruct{})
+		assert.Eq(errTrailingData, err)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	assert := assert.New(t)
+	br := getTestRepo()
+	defer br.Close()
+
+	err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+		err := b.Put([]byte(`A`), []byte(`{"inner":{"a":"x","zz":1},"value":"wrong type","extra":true}`))
+		if err != nil {
+			return err
+		}
+		err = b.Put([]byte(`B`), []byte(`{"inner":{"a":"x"},"value":1}`))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(`C`), []byte(`[1,2]`))
+	})
+	assert.NoError(err)
+
+	results, err := br.Audit(&nestedStruct{})
+	assert.NoError(err)
+
+	// the test data has fields a and b, which nestedStruct doesn't know
+	assert.Cmp([]AuditResult{
+		{Key: []byte(`A`), Fields: []string{`extra`, `inner.zz`}},
+		{Key: []byte(`ABC`), Fields: []string{`a`, `b`}},
+		{Key: []byte(`BCD`), Fields: []string{`a`, `b`}},
+		{Key: []byte(`CDE`), Fields: []string{`a`, `b`}},
+	}, results)
+
+	_, err = br.Audit(nestedStruct{})
+	assert.Error(err)
+}
@@ -0,0 +1,164 @@
+package bbucket
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"go.etcd.io/bbolt"
+)
+
+// PrefixIndex indexes a top-level JSON field for Suggest.
+// The field may hold a string or an array of strings.
+// Values are normalized by lowercasing them, folding accents and collapsing whitespace,
+// so that "Zoë" is suggested for "zo".
+func PrefixIndex(name, field string) Index {
+	return &prefixIndex{name: name, field: field}
+}
+
+type prefixIndex struct {
+	name  string
+	field string
+}
+
+// Suggestion is a value found by Suggest, with the key of the object it belongs to.
+type Suggestion struct {
+	Key   []byte
+	Value string
+}
+
+func (pi *prefixIndex) Name() string {
+	return pi.name
+}
+
+func (pi *prefixIndex) Update(b *bbolt.Bucket, key, old, data []byte) error {
+	for _, v := range pi.values(old) {
+		err := b.Delete(indexEntry([]byte(normalize(v)), key))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range pi.values(data) {
+		err := b.Put(indexEntry([]byte(normalize(v)), key), []byte(v))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// values returns the strings in the indexed field of a stored object.
+// Objects that can't be decoded are not indexed.
+func (pi *prefixIndex) values(data []byte) []string {
+	if data == nil {
+		return nil
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	add := func(v interface{}) {
+		if s, ok := v.(string); ok && normalize(s) != "" {
+			values = append(values, s)
+		}
+	}
+
+	switch v := m[pi.field].(type) {
+	case []interface{}:
+		for _, item := range v {
+			add(item)
+		}
+	default:
+		add(v)
+	}
+
+	return values
+}
+
+// Suggest returns up to limit values starting with prefix, using the first PrefixIndex of the Bucket.
+// The prefix is normalized like the values. Suggestions are sorted by normalized value, then by key.
+// If the Bucket has no PrefixIndex, it returns ErrNoIndex
+func (br Bucket) Suggest(prefix string, limit int) ([]Suggestion, error) {
+	if limit <= 0 {
+		return nil, ErrNonPositiveCount
+	}
+
+	var idx Index
+	for _, i := range br.indexes {
+		if _, ok := i.(*prefixIndex); ok {
+			idx = i
+			break
+		}
+	}
+	if idx == nil {
+		return nil, ErrNoIndex
+	}
+
+	suggestions := []Suggestion{}
+	err := br.View(func(r Reader) error {
+		ib, err := r.indexBucket(idx)
+		if err != nil {
+			return err
+		}
+
+		p := escapeIndexValue([]byte(normalize(prefix)))
+		c := ib.Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p) && len(suggestions) < limit; k, v = c.Next() {
+			suggestions = append(suggestions, Suggestion{Key: clone(indexEntryKey(k)), Value: string(v)})
+		}
+
+		return nil
+	})
+
+	return suggestions, err
+}
+
+// normalize lowercases s, folds accents and collapses whitespace.
+func normalize(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsSpace(r) {
+			space = sb.Len() > 0
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) || unicode.IsControl(r) {
+			continue // combining accents and control characters
+		}
+
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+
+		if folded, ok := accentFolds[r]; ok {
+			sb.WriteString(folded)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+var accentFolds = map[rune]string{}
+
+func init() {
+	for _, fold := range strings.Fields(`àáâãäåāăą:a æ:ae çćĉċč:c ďđ:d èéêëēĕėęě:e ĝğġģ:g ĥħ:h ìíîïĩīĭįı:i ĵ:j ķ:k ĺļľŀł:l
+		ñńņňŉ:n òóôõöøōŏő:o œ:oe ŕŗř:r śŝşšș:s ß:ss ţťŧț:t ùúûüũūŭůűų:u ŵ:w ýÿŷ:y źżž:z þ:th ð:d`) {
+		i := strings.IndexByte(fold, ':')
+		for _, r := range fold[:i] {
+			accentFolds[r] = fold[i+1:]
+		}
+	}
+}
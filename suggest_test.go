@@ -0,0 +1,104 @@
+package bbucket
+
+import (
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+type person struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+func TestNormalize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Eq(`zoe`, normalize(`Zoë`))
+	assert.Eq(`zoe`, normalize("Zoe\u0308")) // combining diaeresis
+	assert.Eq(`strasse cafe`, normalize("  STRAßE \t Café "))
+	assert.Eq(`lodz`, normalize(`Łódź`))
+}
+
+func TestSuggest(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	br, err := Open(br.DB, br.Bucket, WithIndex(PrefixIndex(`names`, `name`)))
+	if err != nil {
+		panic(err)
+	}
+
+	people := map[string]person{
+		`1`: {Name: `Zoë Smith`},
+		`2`: {Name: `zoe adams`},
+		`3`: {Name: `Zack`},
+		`4`: {Name: `Émile`},
+	}
+	for key, p := range people {
+		assert.New(t).NoError(br.Create([]byte(key), p))
+	}
+
+	t.Run(`prefix`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		suggestions, err := br.Suggest(`ZO`, 10)
+		assert.NoError(err)
+		assert.Cmp([]Suggestion{
+			{Key: []byte(`2`), Value: `zoe adams`},
+			{Key: []byte(`1`), Value: `Zoë Smith`},
+		}, suggestions)
+
+		suggestions, err = br.Suggest(`emi`, 10)
+		assert.NoError(err)
+		assert.Cmp([]Suggestion{{Key: []byte(`4`), Value: `Émile`}}, suggestions)
+
+		suggestions, err = br.Suggest(`z`, 2)
+		assert.NoError(err)
+		assert.Eq(2, len(suggestions))
+		assert.Eq(`Zack`, suggestions[0].Value)
+
+		suggestions, err = br.Suggest(`x`, 2)
+		assert.NoError(err)
+		assert.Eq(0, len(suggestions))
+
+		_, err = br.Suggest(`z`, 0)
+		assert.Eq(ErrNonPositiveCount, err)
+	})
+
+	t.Run(`kept in sync`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(br.Patch([]byte(`3`), []byte(`{"name":"Zoltan"}`)))
+		assert.NoError(br.Delete([]byte(`1`)))
+
+		suggestions, err := br.Suggest(`zo`, 10)
+		assert.NoError(err)
+		assert.Cmp([]Suggestion{
+			{Key: []byte(`2`), Value: `zoe adams`},
+			{Key: []byte(`3`), Value: `Zoltan`},
+		}, suggestions)
+	})
+
+	t.Run(`arrays`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		aliases, err := Open(br.DB, br.Bucket, WithIndex(PrefixIndex(`aliases`, `aliases`)))
+		assert.NoError(err)
+		assert.NoError(aliases.Create([]byte(`5`), person{Name: `Robert`, Aliases: []string{`Bob`, `Bobby`}}))
+
+		suggestions, err := aliases.Suggest(`bo`, 10)
+		assert.NoError(err)
+		assert.Cmp([]Suggestion{
+			{Key: []byte(`5`), Value: `Bob`},
+			{Key: []byte(`5`), Value: `Bobby`},
+		}, suggestions)
+	})
+
+	t.Run(`no index`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := Bucket{DB: br.DB, Bucket: br.Bucket}.Suggest(`z`, 1)
+		assert.Eq(ErrNoIndex, err)
+	})
+}
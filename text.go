@@ -0,0 +1,328 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.etcd.io/bbolt"
+)
+
+// TextIndex indexes the words in the given top-level JSON fields for Search.
+// Fields may hold strings or arrays of strings.
+// Words are lowercased and stemmed, and common English stop words are left out.
+func TextIndex(name string, fields ...string) Index {
+	return &textIndex{name: name, fields: fields}
+}
+
+type textIndex struct {
+	name   string
+	fields []string
+}
+
+// Keys in the bucket of a textIndex start with one of these prefixes.
+const (
+	textPosting byte = 'p' // 'p' term 0x00 key: term frequency
+	textLength  byte = 'l' // 'l' key: number of terms in the object
+	textStats   byte = 's' // 's': number of objects and total number of terms
+)
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+func (ti *textIndex) Name() string {
+	return ti.name
+}
+
+func (ti *textIndex) Update(b *bbolt.Bucket, key, old, data []byte) error {
+	docs, total := textStatsOf(b)
+
+	if terms, n, ok := ti.terms(old); ok {
+		for term := range terms {
+			err := b.Delete(textPostingKey(term, key))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := b.Delete(textLengthKey(key))
+		if err != nil {
+			return err
+		}
+
+		if docs > 0 && total >= uint64(n) {
+			docs--
+			total -= uint64(n)
+		}
+	}
+
+	if terms, n, ok := ti.terms(data); ok {
+		for term, tf := range terms {
+			err := b.Put(textPostingKey(term, key), uint32Bytes(uint32(tf)))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := b.Put(textLengthKey(key), uint32Bytes(uint32(n)))
+		if err != nil {
+			return err
+		}
+
+		docs++
+		total += uint64(n)
+	}
+
+	stats := make([]byte, 16)
+	binary.BigEndian.PutUint64(stats, docs)
+	binary.BigEndian.PutUint64(stats[8:], total)
+	return b.Put([]byte{textStats}, stats)
+}
+
+// terms returns the frequency of every term in the indexed fields of a stored object and the number of terms.
+// Objects that can't be decoded are not indexed.
+func (ti *textIndex) terms(data []byte) (map[string]int, int, bool) {
+	if data == nil {
+		return nil, 0, false
+	}
+
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, 0, false
+	}
+
+	terms, n := map[string]int{}, 0
+	add := func(v interface{}) {
+		if s, ok := v.(string); ok {
+			for _, term := range tokenize(s) {
+				terms[term]++
+				n++
+			}
+		}
+	}
+
+	for _, field := range ti.fields {
+		switch v := m[field].(type) {
+		case []interface{}:
+			for _, item := range v {
+				add(item)
+			}
+		default:
+			add(v)
+		}
+	}
+
+	return terms, n, true
+}
+
+// Search finds objects containing any of the words in query, using the first TextIndex of the Bucket.
+// f is called in order of relevance, ranked with BM25, with the score of each object.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// If the Bucket has no TextIndex, it returns ErrNoIndex
+// Get your object of type T using: `*ptr.(*T)`
+func (br Bucket) Search(query string, dst interface{}, f func(key []byte, ptr interface{}, score float64) error) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	var idx Index
+	for _, i := range br.indexes {
+		if _, ok := i.(*textIndex); ok {
+			idx = i
+			break
+		}
+	}
+	if idx == nil {
+		return ErrNoIndex
+	}
+
+	return br.View(func(r Reader) error {
+		ib, err := r.indexBucket(idx)
+		if err != nil {
+			return err
+		}
+
+		b, err := r.bucket()
+		if err != nil {
+			return err
+		}
+
+		results := searchText(ib, query)
+		for _, res := range results {
+			data := b.Get(res.key)
+			if data == nil {
+				continue
+			}
+
+			ptr, err := br.decodeEach("Search", res.key, data, dst)
+			if err != nil {
+				return err
+			}
+			if ptr == nil {
+				continue
+			}
+
+			err = f(res.key, ptr, res.score)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+type textResult struct {
+	key   []byte
+	score float64
+}
+
+// searchText scores the objects in a textIndex bucket for query with BM25, best first.
+func searchText(ib *bbolt.Bucket, query string) []textResult {
+	docs, total := textStatsOf(ib)
+	if docs == 0 {
+		return nil
+	}
+	avgLength := float64(total) / float64(docs)
+
+	scores := map[string]float64{}
+	seen := map[string]bool{}
+	c := ib.Cursor()
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		prefix := textPostingKey(term, nil)
+		var postings []textResult
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if len(v) != 4 {
+				continue
+			}
+			postings = append(postings, textResult{key: k[len(prefix):], score: float64(binary.BigEndian.Uint32(v))})
+		}
+
+		n := float64(len(postings))
+		idf := math.Log((float64(docs)-n+0.5)/(n+0.5) + 1)
+		for _, p := range postings {
+			// postings without a length were left behind by writes around the index, Reindex removes them
+			l := ib.Get(textLengthKey(p.key))
+			if len(l) != 4 {
+				continue
+			}
+
+			length := float64(binary.BigEndian.Uint32(l))
+			tf := p.score
+			scores[string(p.key)] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+		}
+	}
+
+	results := make([]textResult, 0, len(scores))
+	for key, score := range scores {
+		results = append(results, textResult{key: []byte(key), score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return bytes.Compare(results[i].key, results[j].key) < 0
+	})
+
+	return results
+}
+
+func textStatsOf(b *bbolt.Bucket) (docs, total uint64) {
+	stats := b.Get([]byte{textStats})
+	if len(stats) != 16 {
+		return 0, 0
+	}
+
+	return binary.BigEndian.Uint64(stats), binary.BigEndian.Uint64(stats[8:])
+}
+
+func textPostingKey(term string, key []byte) []byte {
+	k := append([]byte{textPosting}, term...)
+	k = append(k, 0x00)
+	return append(k, key...)
+}
+
+func textLengthKey(key []byte) []byte {
+	return append([]byte{textLength}, key...)
+}
+
+func uint32Bytes(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+// tokenize splits text into lowercased, stemmed words, leaving out stop words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := words[:0]
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		terms = append(terms, stem(w))
+	}
+
+	return terms
+}
+
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a an and are as at be but by for from has have he her his i if in into is
+		it its me my no not of on or our she so that the their them then there these they this to was we were
+		what when which who will with you your`) {
+		stopWords[w] = true
+	}
+}
+
+// stem strips common English suffixes, so that e.g. "tickets", "ticketing" and "ticketed" match.
+// It is much simpler than a Porter stemmer and only handles plurals, -ing, -ed and -ly.
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 4 && strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	case len(w) > 5 && strings.HasSuffix(w, "ing"):
+		return undouble(w[:len(w)-3])
+	case len(w) > 4 && strings.HasSuffix(w, "ed"):
+		return undouble(w[:len(w)-2])
+	case len(w) > 5 && strings.HasSuffix(w, "ly"):
+		return w[:len(w)-2]
+	}
+
+	return w
+}
+
+// undouble removes a doubled final consonant left by stripping a suffix, as in "running".
+func undouble(w string) string {
+	n := len(w)
+	if n >= 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+		return w[:n-1]
+	}
+
+	return w
+}
@@ -0,0 +1,137 @@
+package bbucket
+
+import (
+	"sort"
+	"testing"
+
+	"git.fuyu.moe/Fuyu/assert"
+	"go.etcd.io/bbolt"
+)
+
+type article struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Body  string   `json:"body"`
+	Tags  []string `json:"tags"`
+}
+
+func search(br Bucket, query string) ([]string, []float64, error) {
+	var keys []string
+	var scores []float64
+	err := br.Search(query, &article{}, func(key []byte, ptr interface{}, score float64) error {
+		if string(key) != ptr.(*article).ID {
+			return ErrKeyChanged
+		}
+		keys = append(keys, string(key))
+		scores = append(scores, score)
+		return nil
+	})
+
+	return keys, scores, err
+}
+
+func TestTokenize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Cmp([]string{`ticket`, `ticket`, `ticket`, `run`, `pony`, `quick`, `class`, `42`, `über`},
+		tokenize(`The tickets, ticketing; TICKETED! running ponies quickly class 42 Über`))
+	assert.Eq(0, len(tokenize(`it is what it is`)))
+}
+
+func TestSearch(t *testing.T) {
+	br := getTestRepo()
+	defer br.Close()
+
+	br, err := Open(br.DB, br.Bucket, WithIndex(TextIndex(`text`, `title`, `body`, `tags`)))
+	if err != nil {
+		panic(err)
+	}
+
+	articles := []article{
+		{ID: `1`, Title: `Login fails`, Body: `Users cannot login after the update`, Tags: []string{`auth`}},
+		{ID: `2`, Title: `Slow search`, Body: `Searching tickets is slow, very slow`},
+		{ID: `3`, Title: `Update docs`, Body: `The docs mention an old login page`},
+	}
+	for _, a := range articles {
+		assert.New(t).NoError(br.Create([]byte(a.ID), a))
+	}
+
+	t.Run(`ranked`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		keys, scores, err := search(br, `login`)
+		assert.NoError(err)
+		assert.Cmp([]string{`1`, `3`}, keys)
+		assert.Eq(true, scores[0] > scores[1])
+
+		keys, _, err = search(br, `slow tickets`)
+		assert.NoError(err)
+		assert.Cmp([]string{`2`}, keys)
+
+		keys, _, err = search(br, `auth update`)
+		assert.NoError(err)
+		assert.Cmp([]string{`1`, `3`}, keys)
+
+		keys, _, err = search(br, `nothing the`)
+		assert.NoError(err)
+		assert.Eq(0, len(keys))
+	})
+
+	t.Run(`kept in sync`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(br.Update([]byte(`2`), &article{}, func(ptr interface{}) (interface{}, error) {
+			a := *ptr.(*article)
+			a.Body = `Login is slow`
+			return a, nil
+		}))
+		keys, _, err := search(br, `login`)
+		assert.NoError(err)
+		sort.Strings(keys)
+		assert.Cmp([]string{`1`, `2`, `3`}, keys)
+
+		keys, _, err = search(br, `tickets`)
+		assert.NoError(err)
+		assert.Eq(0, len(keys))
+
+		assert.NoError(br.Delete([]byte(`1`)))
+		keys, scores, err := search(br, `login`)
+		assert.NoError(err)
+		assert.Cmp([]string{`2`, `3`}, keys)
+
+		assert.NoError(br.Reindex())
+		keys, reindexed, err := search(br, `login`)
+		assert.NoError(err)
+		assert.Cmp([]string{`2`, `3`}, keys)
+		assert.Cmp(scores, reindexed)
+	})
+
+	t.Run(`broken index`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NoError(br.DB.Update(func(tx *bbolt.Tx) error {
+			ib := tx.Bucket(br.indexName(br.indexes[0]))
+			err := ib.Delete(textLengthKey([]byte(`2`)))
+			if err != nil {
+				return err
+			}
+			return ib.Put(textPostingKey(`login`, []byte(`3`)), []byte{1})
+		}))
+
+		keys, _, err := search(br, `login`)
+		assert.NoError(err)
+		assert.Eq(0, len(keys))
+
+		assert.NoError(br.Reindex())
+		keys, _, err = search(br, `login`)
+		assert.NoError(err)
+		assert.Cmp([]string{`2`, `3`}, keys)
+	})
+
+	t.Run(`no index`, func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, _, err := search(Bucket{DB: br.DB, Bucket: br.Bucket}, `login`)
+		assert.Eq(ErrNoIndex, err)
+	})
+}
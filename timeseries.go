@@ -0,0 +1,216 @@
+package bbucket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// TimeSeries stores objects by time. Keys are the time in nanoseconds followed by a sequence number,
+// both big endian, so objects sort by time and objects with the same time keep their order.
+// Times are stored with nanosecond precision between the years 1678 and 2262.
+// Use a separate bucket for every series.
+type TimeSeries struct {
+	br Bucket
+}
+
+// Sample is the aggregated value of the objects in one interval of Downsample.
+type Sample struct {
+	Start time.Time
+	Count int
+	Value float64
+}
+
+// Aggregator combines the values of an interval in Downsample. values is never empty.
+// DownsampleSum, DownsampleMean, DownsampleMin and DownsampleMax are provided.
+type Aggregator func(values []float64) float64
+
+// TimeSeries returns a TimeSeries that stores its objects in the bucket.
+func (br Bucket) TimeSeries() TimeSeries {
+	return TimeSeries{br: br}
+}
+
+// Append stores obj at time t and returns its key.
+func (ts TimeSeries) Append(t time.Time, obj interface{}) ([]byte, error) {
+	var key []byte
+	err := ts.br.write(func(b *bbolt.Bucket) error {
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key = timeSeriesKey(t, seq)
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return ts.br.keyError("Append", key, err)
+		}
+
+		return ts.br.put(b, key, data)
+	})
+
+	return key, err
+}
+
+// Between calls f for every object with from <= t < to, in order of time.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// Get your object of type T using: `*ptr.(*T)`
+func (ts TimeSeries) Between(from, to time.Time, dst interface{}, f func(t time.Time, ptr interface{}) error) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
+
+	return ts.br.View(func(r Reader) error {
+		return r.Range(timeSeriesKey(from, 0), timeSeriesKey(to, 0), dst, func(key []byte, ptr interface{}) error {
+			return f(timeSeriesTime(key), ptr)
+		})
+	})
+}
+
+// Downsample splits [from, to) into intervals of the given length and aggregates
+// the values returned by value for the objects in every interval.
+// Intervals start at multiples of interval since the zero time, e.g. on whole minutes or hours.
+// Only intervals that contain objects are returned.
+func (ts TimeSeries) Downsample(from, to time.Time, interval time.Duration, dst interface{}, value func(ptr interface{}) (float64, error), agg Aggregator) ([]Sample, error) {
+	if value == nil || agg == nil {
+		return nil, ErrNilFuncPassed
+	}
+
+	if interval <= 0 {
+		return nil, ErrNonPositiveCount
+	}
+
+	samples := []Sample{}
+	var values []float64
+	flush := func() {
+		if len(values) > 0 {
+			last := &samples[len(samples)-1]
+			last.Count = len(values)
+			last.Value = agg(values)
+			values = values[:0]
+		}
+	}
+
+	err := ts.Between(from, to, dst, func(t time.Time, ptr interface{}) error {
+		v, err := value(ptr)
+		if err != nil {
+			return err
+		}
+
+		start := t.Truncate(interval)
+		if len(samples) == 0 || !samples[len(samples)-1].Start.Equal(start) {
+			flush()
+			samples = append(samples, Sample{Start: start})
+		}
+		values = append(values, v)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	flush()
+	return samples, nil
+}
+
+// DropBefore deletes all objects older than t in a single transaction and returns how many were deleted.
+func (ts TimeSeries) DropBefore(t time.Time) (int, error) {
+	var n int
+	err := ts.br.BucketUpdate(func(b *bbolt.Bucket) error {
+		var keys [][]byte
+		end := timeSeriesKey(t, 0)
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Next() {
+			keys = append(keys, clone(k))
+		}
+
+		for _, k := range keys {
+			err := ts.br.del(b, k)
+			if err != nil {
+				return err
+			}
+		}
+
+		n = len(keys)
+		return nil
+	})
+
+	return n, err
+}
+
+// Retain deletes all objects older than d and returns how many were deleted.
+func (ts TimeSeries) Retain(d time.Duration) (int, error) {
+	return ts.DropBefore(time.Now().Add(-d))
+}
+
+// DownsampleSum adds the values of an interval.
+func DownsampleSum(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum
+}
+
+// DownsampleMean averages the values of an interval.
+func DownsampleMean(values []float64) float64 {
+	return DownsampleSum(values) / float64(len(values))
+}
+
+// DownsampleMin returns the lowest value of an interval.
+func DownsampleMin(values []float64) float64 {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
+
+// DownsampleMax returns the highest value of an interval.
+func DownsampleMax(values []float64) float64 {
+	max := values[0]
+	for _, v := range values[1:] {
+		if v > max {
+			max = v
+		}
+	}
+
+	return max
+}
+
+var (
+	minSeriesTime = time.Unix(0, math.MinInt64)
+	maxSeriesTime = time.Unix(0, math.MaxInt64)
+)
+
+// timeSeriesKey encodes t so that keys sort by time, including times before 1970.
+// Times outside the range of UnixNano are clamped, so they can still be used as bounds.
+func timeSeriesKey(t time.Time, seq uint64) []byte {
+	nanos := t.UnixNano()
+	if t.Before(minSeriesTime) {
+		nanos = math.MinInt64
+	} else if t.After(maxSeriesTime) {
+		nanos = math.MaxInt64
+	}
+
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(nanos)^1<<63)
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+func timeSeriesTime(key []byte) time.Time {
+	if len(key) < 8 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)^1<<63))
+}
@@ -0,0 +1,133 @@
+package bbucket
+
+import (
+	"testing"
+	"time"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+type reading struct {
+	Value float64 `json:"value"`
+}
+
+func getTimeSeries() (Bucket, TimeSeries) {
+	br := getTestRepo()
+	_ = br.DeleteAll(&testStruct{}, func([]byte, interface{}) (bool, error) {
+		return true, nil
+	})
+
+	return br, br.TimeSeries()
+}
+
+func TestTimeSeries(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run(`append and between`, func(t *testing.T) {
+		assert := assert.New(t)
+		br, ts := getTimeSeries()
+		defer br.Close()
+
+		times := []time.Time{start.Add(time.Minute), start, start.Add(time.Minute), time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)}
+		for i, at := range times {
+			_, err := ts.Append(at, reading{float64(i)})
+			assert.NoError(err)
+		}
+
+		var actual []float64
+		var at []time.Time
+		err := ts.Between(start, start.Add(time.Hour), &reading{}, func(t time.Time, ptr interface{}) error {
+			at = append(at, t)
+			actual = append(actual, ptr.(*reading).Value)
+			return nil
+		})
+		assert.NoError(err)
+		assert.Cmp([]float64{1, 0, 2}, actual)
+		assert.Eq(true, at[0].Equal(start))
+		assert.Eq(true, at[2].Equal(start.Add(time.Minute)))
+
+		actual = nil
+		err = ts.Between(time.Time{}, start, &reading{}, func(t time.Time, ptr interface{}) error {
+			actual = append(actual, ptr.(*reading).Value)
+			return nil
+		})
+		assert.NoError(err)
+		assert.Cmp([]float64{3}, actual)
+
+		actual = nil
+		err = ts.Between(time.Time{}, time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), &reading{}, func(t time.Time, ptr interface{}) error {
+			actual = append(actual, ptr.(*reading).Value)
+			return nil
+		})
+		assert.NoError(err)
+		assert.Cmp([]float64{3, 1, 0, 2}, actual)
+	})
+
+	t.Run(`downsample`, func(t *testing.T) {
+		assert := assert.New(t)
+		br, ts := getTimeSeries()
+		defer br.Close()
+
+		for i, offset := range []time.Duration{0, 10 * time.Second, 59 * time.Second, 3 * time.Minute, 3*time.Minute + time.Second} {
+			_, err := ts.Append(start.Add(offset), reading{float64(i)})
+			assert.NoError(err)
+		}
+
+		value := func(ptr interface{}) (float64, error) {
+			return ptr.(*reading).Value, nil
+		}
+
+		samples, err := ts.Downsample(start, start.Add(time.Hour), time.Minute, &reading{}, value, DownsampleMean)
+		assert.NoError(err)
+		assert.Eq(2, len(samples))
+		assert.Eq(true, samples[0].Start.Equal(start))
+		assert.Eq(3, samples[0].Count)
+		assert.Eq(1.0, samples[0].Value)
+		assert.Eq(true, samples[1].Start.Equal(start.Add(3*time.Minute)))
+		assert.Eq(2, samples[1].Count)
+		assert.Eq(3.5, samples[1].Value)
+
+		samples, err = ts.Downsample(start, start.Add(time.Hour), time.Hour, &reading{}, value, DownsampleMax)
+		assert.NoError(err)
+		assert.Cmp([]float64{4}, []float64{samples[0].Value})
+
+		samples, err = ts.Downsample(start, start.Add(time.Hour), time.Hour, &reading{}, value, DownsampleMin)
+		assert.NoError(err)
+		assert.Eq(0.0, samples[0].Value)
+
+		samples, err = ts.Downsample(start, start.Add(time.Hour), time.Hour, &reading{}, value, DownsampleSum)
+		assert.NoError(err)
+		assert.Eq(10.0, samples[0].Value)
+
+		samples, err = ts.Downsample(start.Add(time.Hour), start.Add(2*time.Hour), time.Hour, &reading{}, value, DownsampleSum)
+		assert.NoError(err)
+		assert.Eq(0, len(samples))
+
+		_, err = ts.Downsample(start, start.Add(time.Hour), 0, &reading{}, value, DownsampleSum)
+		assert.Eq(ErrNonPositiveCount, err)
+	})
+
+	t.Run(`retention`, func(t *testing.T) {
+		assert := assert.New(t)
+		br, ts := getTimeSeries()
+		defer br.Close()
+
+		now := time.Now()
+		for _, age := range []time.Duration{48 * time.Hour, 25 * time.Hour, time.Hour, 0} {
+			_, err := ts.Append(now.Add(-age), reading{})
+			assert.NoError(err)
+		}
+
+		n, err := ts.Retain(24 * time.Hour)
+		assert.NoError(err)
+		assert.Eq(2, n)
+
+		count, err := br.Count()
+		assert.NoError(err)
+		assert.Eq(2, count)
+
+		n, err = ts.DropBefore(now.Add(time.Second))
+		assert.NoError(err)
+		assert.Eq(2, n)
+	})
+}
@@ -11,21 +11,23 @@ import (
 // If the key does not exist, it return ErrObjectNotFound
 // f receives a pointer to an object to be modified.
 // It should return the modified object, not a pointer.
+// dst is zeroed before decoding, so fields of a previous object don't leak into it.
 // Get your object of type T using: `*ptr.(*T)`
 func (br Bucket) Update(key []byte, dst interface{}, f func(ptr interface{}) (object interface{}, err error)) error {
-	return br.BucketUpdate(func(b *bbolt.Bucket) error {
+	return br.write(func(b *bbolt.Bucket) error {
 		if f == nil {
 			return ErrNilFuncPassed
 		}
 
 		data := b.Get(key)
 		if data == nil {
-			return ErrObjectNotFound
+			return br.keyError("Update", key, ErrObjectNotFound)
 		}
 
-		err := json.Unmarshal(data, dst)
+		zero(dst)
+		err := br.decode(data, dst)
 		if err != nil {
-			return err
+			return br.keyError("Update", key, err)
 		}
 
 		obj, err := f(dst)
@@ -35,77 +37,244 @@ func (br Bucket) Update(key []byte, dst interface{}, f func(ptr interface{}) (ob
 
 		data, err = json.Marshal(obj)
 		if err != nil {
-			return err
+			return br.keyError("Update", key, err)
 		}
 
-		return b.Put(key, data)
+		return br.put(b, key, data)
 	})
 }
 
+// UpdateAll iterates over all objects in the bucket in a single transaction.
+// f receives a pointer to a newly allocated object of the same type as dst.
+// It should return the key and the object to be stored, not a pointer.
+// Returning a nil key deletes the object, returning a different key moves it.
+// If an object is moved to a key that is already taken, the CollisionPolicy applies.
+// Get your object of type T using: `*ptr.(*T)`
 func (br Bucket) UpdateAll(dst interface{}, f func(ptr interface{}) (key []byte, object interface{}, err error)) error {
 	return br.BucketUpdate(func(b *bbolt.Bucket) error {
 		if f == nil {
 			return ErrNilFuncPassed
 		}
 
-		type item struct {
-			key  []byte
-			data []byte
+		p := newPendingUpdates()
+		err := b.ForEach(func(originalKey, originalValue []byte) error {
+			return br.collectUpdate(p, "UpdateAll", originalKey, originalValue, dst, f)
+		})
+		if err != nil {
+			return err
 		}
-		toBeDeleted := [][]byte{}
-		toBePut := []item{}
 
-		err := b.ForEach(func(originalKey, originalValue []byte) error {
-			err := json.Unmarshal(originalValue, dst)
-			if err != nil {
-				return err
-			}
+		err = br.resolveCollisions(b, "UpdateAll", dst, p)
+		if err != nil {
+			return err
+		}
 
-			key, object, err := f(dst)
-			if err != nil {
-				return err
-			}
+		return p.apply(br, b)
+	})
+}
 
-			if key == nil { // delete item
-				toBeDeleted = append(toBeDeleted, originalKey)
-				return nil
-			}
+// UpdateAllChunked works like UpdateAll, but commits every size objects in a separate transaction.
+// This keeps transactions small and lets other writers in between chunks, at the cost of atomicity:
+// if an error occurs, the chunks that were already committed stay committed.
+// After every chunk, progress (if not nil) receives the number of objects processed so far
+// and the last processed key. Returning an error from progress stops the update.
+//
+// Objects moved to a key that has yet to be visited stay at their original key until the chunk that visits it,
+// and so do objects moved onto the original key of such an object. Their moves are held in memory meanwhile,
+// so memory use grows with the number of waiting moves. Moved objects are not passed to f again,
+// and collisions are resolved like in UpdateAll, e.g. objects in different chunks can swap keys.
+func (br Bucket) UpdateAllChunked(dst interface{}, size int, f func(ptr interface{}) (key []byte, object interface{}, err error), progress func(done int, lastKey []byte) error) error {
+	if f == nil {
+		return ErrNilFuncPassed
+	}
 
-			data, err := json.Marshal(object)
-			if err != nil {
-				return err
+	if size <= 0 {
+		return ErrNonPositiveCount
+	}
+
+	var lastKey []byte
+	var waiting []pendingPut // moves to keys that have yet to be visited, in key order of the moved objects
+	done := 0
+	for {
+		n, end := 0, false
+		var stillWaiting []pendingPut
+		err := br.BucketUpdate(func(b *bbolt.Bucket) error {
+			p := newPendingUpdates()
+			c := b.Cursor()
+
+			k, v := c.First()
+			if lastKey != nil {
+				k, v = c.Seek(lastKey)
+				if bytes.Equal(k, lastKey) {
+					k, v = c.Next()
+				}
 			}
 
-			if !bytes.Equal(originalKey, key) { // move item to new key, possibly with new value
-				toBeDeleted = append(toBeDeleted, originalKey)
-				toBePut = append(toBePut, item{key: key, data: data})
-				return nil
+			for visited := 0; visited < size; k, v = c.Next() {
+				if k == nil {
+					end = true
+					break
+				}
+
+				visited++
+				lastKey = append(lastKey[:0], k...)
+				err := br.collectUpdate(p, "UpdateAllChunked", k, v, dst, f)
+				if err != nil {
+					return err
+				}
+				n++
 			}
 
-			if !bytes.Equal(originalValue, data) { // edit item but not key
-				toBePut = append(toBePut, item{key, data})
+			stillWaiting = p.deferMoves(waiting, lastKey, end)
+			err := br.resolveCollisions(b, "UpdateAllChunked", dst, p)
+			if err != nil {
+				return err
 			}
 
-			return nil
+			return p.apply(br, b)
 		})
 		if err != nil {
 			return err
 		}
+		waiting = stillWaiting
 
-		for _, key := range toBeDeleted {
-			err := b.Delete(key)
+		done += n
+		if progress != nil && n > 0 {
+			err = progress(done, append([]byte{}, lastKey...))
 			if err != nil {
 				return err
 			}
 		}
 
-		for _, item := range toBePut {
-			err := b.Put(item.key, item.data)
-			if err != nil {
-				return err
+		if end {
+			return nil
+		}
+	}
+}
+
+// deferMoves takes the moves that have to wait out of p and returns them, along with the waiting moves that still have to.
+// A move waits while its key is after lastKey, or while its key is the original key of another waiting move.
+// Waiting moves that don't have to wait anymore are added to p. At the end of the bucket, nothing waits.
+func (p *pendingUpdates) deferMoves(waiting []pendingPut, lastKey []byte, end bool) []pendingPut {
+	moves := append(append([]pendingPut{}, waiting...), p.toBePut...)
+	wait := make([]bool, len(moves))
+	waitingFrom := map[string]bool{}
+	for changed := !end; changed; {
+		changed = false
+		for i, put := range moves {
+			if wait[i] || bytes.Equal(put.from, put.key) {
+				continue
 			}
+
+			if bytes.Compare(put.key, lastKey) > 0 || waitingFrom[string(put.key)] {
+				wait[i] = true
+				waitingFrom[string(put.from)] = true
+				changed = true
+			}
+		}
+	}
+
+	var stillWaiting, puts []pendingPut
+	var deletes [][]byte
+	for i, put := range moves {
+		switch {
+		case wait[i]:
+			// keys read in this transaction are only valid until it ends
+			put.from, put.key = clone(put.from), clone(put.key)
+			stillWaiting = append(stillWaiting, put)
+		case i < len(waiting):
+			puts = append(puts, put)
+			deletes = append(deletes, put.from)
+		default:
+			puts = append(puts, put)
+		}
+	}
+
+	for _, key := range p.toBeDeleted {
+		if !waitingFrom[string(key)] {
+			deletes = append(deletes, key)
 		}
+	}
+
+	p.toBePut, p.toBeDeleted = puts, deletes
+	return stillWaiting
+}
+
+type pendingPut struct {
+	from    []byte
+	key     []byte
+	data    []byte
+	dropped bool
+}
 
+// pendingUpdates collects the changes made by UpdateAll, so they can be applied after iterating.
+type pendingUpdates struct {
+	toBeDeleted [][]byte
+	toBePut     []pendingPut
+	kept        map[string]bool // keys in toBeDeleted that should not be deleted after all
+}
+
+func newPendingUpdates() *pendingUpdates {
+	return &pendingUpdates{kept: map[string]bool{}}
+}
+
+// collectUpdate decodes an object, passes it to f and records the resulting change.
+func (br Bucket) collectUpdate(p *pendingUpdates, op string, originalKey, originalValue []byte, dst interface{}, f func(ptr interface{}) (key []byte, object interface{}, err error)) error {
+	ptr, err := br.decodeEach(op, originalKey, originalValue, dst)
+	if err != nil || ptr == nil {
+		return err
+	}
+
+	key, object, err := f(ptr)
+	if err != nil {
+		return err
+	}
+
+	if key == nil { // delete item
+		p.toBeDeleted = append(p.toBeDeleted, originalKey)
 		return nil
-	})
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return br.keyError(op, key, err)
+	}
+
+	if !bytes.Equal(originalKey, key) { // move item to new key, possibly with new value
+		p.toBeDeleted = append(p.toBeDeleted, originalKey)
+		p.toBePut = append(p.toBePut, pendingPut{from: originalKey, key: key, data: data})
+		return nil
+	}
+
+	if !bytes.Equal(originalValue, data) { // edit item but not key
+		p.toBePut = append(p.toBePut, pendingPut{from: originalKey, key: key, data: data})
+	}
+
+	return nil
+}
+
+func (p *pendingUpdates) apply(br Bucket, b *bbolt.Bucket) error {
+	for _, key := range p.toBeDeleted {
+		if p.kept[string(key)] {
+			continue
+		}
+
+		err := br.del(b, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, item := range p.toBePut {
+		if item.dropped {
+			continue
+		}
+
+		err := br.put(b, item.key, item.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
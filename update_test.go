@@ -2,10 +2,12 @@ package bbucket
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	"git.fuyu.moe/Fuyu/assert"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
@@ -49,7 +51,7 @@ func TestUpdate(t *testing.T) {
 			return nil, nil
 		})
 
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
 	})
 
 	t.Run(`invalid destination`, func(t *testing.T) {
@@ -155,7 +157,7 @@ func TestUpdateAll(t *testing.T) {
 		assert.NoError(err)
 
 		err = br.Get([]byte(`ABC`), &o)
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
 	})
 
 	t.Run(`update key`, func(t *testing.T) {
@@ -165,7 +167,7 @@ func TestUpdateAll(t *testing.T) {
 
 		o := testStruct{}
 		err := br.Get([]byte(`bla`), &o)
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
 
 		err = br.UpdateAll(&testStruct{}, func(ptr interface{}) (key []byte, object interface{}, err error) {
 			o := *ptr.(*testStruct)
@@ -207,7 +209,7 @@ func TestUpdateAll(t *testing.T) {
 
 		o := testStruct{}
 		err := br.Get([]byte(`bla`), &o)
-		assert.Eq(ErrObjectNotFound, err)
+		assert.Eq(true, errors.Is(err, ErrObjectNotFound))
 
 		err = br.UpdateAll(&testStruct{}, func(ptr interface{}) (key []byte, object interface{}, err error) {
 			o := *ptr.(*testStruct)
@@ -231,3 +233,193 @@ func assertUnchanged(assert assert.Assert, br Bucket) {
 		return strings.Compare(a.ID, b.ID) < 0
 	}))
 }
+
+func TestUpdateAllChunked(t *testing.T) {
+	t.Run(`invalid arguments`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		err := br.UpdateAllChunked(&testStruct{}, 2, nil, nil)
+		assert.Eq(ErrNilFuncPassed, err)
+
+		err = br.UpdateAllChunked(&testStruct{}, 0, func(ptr interface{}) ([]byte, interface{}, error) {
+			return nil, nil, nil
+		}, nil)
+		assert.Eq(ErrNonPositiveCount, err)
+	})
+
+	t.Run(`update values with progress`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		type report struct {
+			done    int
+			lastKey string
+		}
+		var reports []report
+
+		err := br.UpdateAllChunked(&testStruct{}, 2, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*testStruct)
+			o.Data++
+			return o.Key(), o, nil
+		}, func(done int, lastKey []byte) error {
+			reports = append(reports, report{done, string(lastKey)})
+			return nil
+		})
+		assert.NoError(err)
+		assert.Cmp([]report{{2, `BCD`}, {3, `CDE`}}, reports, cmp.AllowUnexported(report{}))
+
+		expected := []testStruct{{`ABC`, 124}, {`BCD`, 235}, {`CDE`, 346}}
+		assert.Cmp(expected, getAllTestStructs(br))
+	})
+
+	t.Run(`moved objects are not visited again`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		calls := map[string]int{}
+		err := br.UpdateAllChunked(&testStruct{}, 1, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*testStruct)
+			calls[o.ID]++
+			if o.ID == `ABC` {
+				return []byte(`ZZZ`), o, nil
+			}
+			return o.Key(), o, nil
+		}, nil)
+		assert.NoError(err)
+		assert.Cmp(map[string]int{`ABC`: 1, `BCD`: 1, `CDE`: 1}, calls)
+
+		err = br.Get([]byte(`ZZZ`), &testStruct{})
+		assert.NoError(err)
+	})
+
+	t.Run(`moves across chunks match UpdateAll`, func(t *testing.T) {
+		policies := map[string]Option{
+			`fail`:          OnCollision(CollisionFail),
+			`keep existing`: OnCollision(CollisionKeepExisting),
+			`overwrite`:     OnCollision(CollisionOverwrite),
+			`merge`: MergeOnCollision(func(_ []byte, existing, incoming interface{}) (interface{}, error) {
+				o := *existing.(*testStruct)
+				o.Data += incoming.(*testStruct).Data
+				return o, nil
+			}),
+		}
+		moves := []map[string]string{
+			{`ABC`: `BCD`, `BCD`: `ABC`},               // swap
+			{`ABC`: `BCD`, `BCD`: `CDE`, `CDE`: `ABC`}, // rotate
+			{`ABC`: `CDE`},                             // onto an object that stays
+			{`ABC`: `ZZZ`, `BCD`: `ZZZ`},               // two objects onto a free key
+		}
+
+		for name, policy := range policies {
+			for i, m := range moves {
+				policy, m := policy, m
+				t.Run(fmt.Sprintf(`%s %d`, name, i), func(t *testing.T) {
+					assert := assert.New(t)
+
+					br := getTestRepo()
+					policy(&br)
+					errAll := br.UpdateAll(&testStruct{}, moveTo(m))
+					expected := getAllByKey(br)
+					br.Close()
+
+					br = getTestRepo()
+					policy(&br)
+					errChunked := br.UpdateAllChunked(&testStruct{}, 1, moveTo(m), nil)
+					actual := getAllByKey(br)
+					br.Close()
+
+					assert.Eq(errAll == nil, errChunked == nil)
+					assert.Cmp(expected, actual)
+				})
+			}
+		}
+	})
+
+	t.Run(`moves waiting across many chunks`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		type padded struct {
+			ID  string `json:"id"`
+			Pad string `json:"pad"`
+		}
+
+		assert.NoError(br.DeleteAll(&testStruct{}, func([]byte, interface{}) (bool, error) {
+			return true, nil
+		}))
+
+		// enough objects that pages are freed and reused between chunks
+		var objs []padded
+		for i := 0; i < 3000; i++ {
+			objs = append(objs, padded{ID: fmt.Sprintf(`k%04d`, i), Pad: strings.Repeat(`x`, 200)})
+		}
+		assert.NoError(br.CreateAll(objs, func(obj interface{}) ([]byte, error) {
+			return []byte(obj.(padded).ID), nil
+		}))
+
+		err := br.UpdateAllChunked(&padded{}, 50, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*padded)
+			if o.ID < `k0400` && o.ID[4]%2 == 0 {
+				o.Pad = `moved`
+				return []byte(`z` + o.ID), o, nil
+			}
+			// rewriting the other objects frees the pages the waiting keys were read from
+			o.Pad = strings.Repeat(`y`, 300)
+			return []byte(o.ID), o, nil
+		}, nil)
+		assert.NoError(err)
+
+		count, err := br.Count()
+		assert.NoError(err)
+		assert.Eq(3000, count)
+
+		for i := 0; i < 3000; i++ {
+			id := fmt.Sprintf(`k%04d`, i)
+			key, pad := id, strings.Repeat(`y`, 300)
+			if i < 400 && i%2 == 0 {
+				key, pad = `z`+id, `moved`
+			}
+
+			var o padded
+			assert.NoError(br.Get([]byte(key), &o))
+			assert.Eq(padded{ID: id, Pad: pad}, o)
+		}
+	})
+
+	t.Run(`committed chunks stay committed`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		myErr := errors.New(`custom error`)
+		err := br.UpdateAllChunked(&testStruct{}, 2, func(ptr interface{}) ([]byte, interface{}, error) {
+			o := *ptr.(*testStruct)
+			if o.ID == `CDE` {
+				return nil, nil, myErr
+			}
+			return nil, nil, nil
+		}, nil)
+		assert.Eq(myErr, err)
+		assert.Cmp([]testStruct{testStruct3}, getAllTestStructs(br))
+	})
+
+	t.Run(`progress error stops`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		myErr := errors.New(`custom error`)
+		err := br.UpdateAllChunked(&testStruct{}, 1, func(ptr interface{}) ([]byte, interface{}, error) {
+			return nil, nil, nil
+		}, func(done int, lastKey []byte) error {
+			return myErr
+		})
+		assert.Eq(myErr, err)
+		assert.Cmp([]testStruct{testStruct2, testStruct3}, getAllTestStructs(br))
+	})
+}
@@ -0,0 +1,220 @@
+package bbucket
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// WriteBehindConfig configures a WriteBehind.
+type WriteBehindConfig struct {
+	// Interval between flushes. Zero disables periodic flushing.
+	Interval time.Duration
+	// MaxOps triggers a flush once this many writes are queued. Zero means no limit.
+	MaxOps int
+	// OnError receives the errors of queued writes, e.g. a KeyError with ErrObjectAlreadyExists
+	// for a Create, and errors of flushes that run in the background.
+	OnError func(err error)
+}
+
+// WriteBehind queues writes in memory and stores them in a single transaction per flush.
+// This trades durability for throughput: queued writes are lost if the process dies,
+// and reads on the Bucket don't see them until they are flushed.
+// Writes are stored in the order they were queued, so the order per key is preserved.
+type WriteBehind struct {
+	br  Bucket
+	cfg WriteBehindConfig
+
+	mu     sync.Mutex
+	queue  []queuedWrite
+	closed bool
+
+	flushMu sync.Mutex
+	kick    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type writeKind int
+
+const (
+	writeCreate writeKind = iota
+	writePut
+	writeDelete
+)
+
+type queuedWrite struct {
+	kind writeKind
+	key  []byte
+	data []byte
+}
+
+// NewWriteBehind returns a WriteBehind for the bucket and starts flushing in the background.
+// Call Close to stop it and store the remaining writes.
+func NewWriteBehind(br Bucket, cfg WriteBehindConfig) *WriteBehind {
+	w := &WriteBehind{
+		br:   br,
+		cfg:  cfg,
+		kick: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w
+}
+
+// Create queues a new object. If the key exists when it is flushed,
+// OnError receives ErrObjectAlreadyExists and the object is not stored.
+// The object is encoded immediately, so it can be modified after Create returns.
+func (w *WriteBehind) Create(key []byte, obj interface{}) error {
+	return w.enqueue(writeCreate, key, obj)
+}
+
+// Put queues an object, overwriting any existing object with the same key.
+func (w *WriteBehind) Put(key []byte, obj interface{}) error {
+	return w.enqueue(writePut, key, obj)
+}
+
+// Delete queues the deletion of an object. If the key doesn't exist when it is flushed,
+// OnError receives ErrObjectNotFound
+func (w *WriteBehind) Delete(key []byte) error {
+	return w.enqueue(writeDelete, key, nil)
+}
+
+func (w *WriteBehind) enqueue(kind writeKind, key []byte, obj interface{}) error {
+	var data []byte
+	if kind != writeDelete {
+		var err error
+		data, err = json.Marshal(obj)
+		if err != nil {
+			return w.br.keyError("WriteBehind", key, err)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriteBehindClosed
+	}
+
+	w.queue = append(w.queue, queuedWrite{kind: kind, key: clone(key), data: data})
+	if w.cfg.MaxOps > 0 && len(w.queue) >= w.cfg.MaxOps {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Flush stores all queued writes in a single transaction.
+// It returns an error if the transaction fails, in which case the queued writes are dropped.
+// Errors of individual writes are passed to OnError.
+func (w *WriteBehind) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	queue := w.queue
+	w.queue = nil
+	w.mu.Unlock()
+
+	if len(queue) == 0 {
+		return nil
+	}
+
+	var errs []error
+	err := w.br.BucketUpdate(func(b *bbolt.Bucket) error {
+		errs = errs[:0]
+		for _, q := range queue {
+			switch q.kind {
+			case writeCreate:
+				if b.Get(q.key) != nil {
+					errs = append(errs, w.br.keyError("WriteBehind", q.key, ErrObjectAlreadyExists))
+					continue
+				}
+			case writeDelete:
+				if b.Get(q.key) == nil {
+					errs = append(errs, w.br.keyError("WriteBehind", q.key, ErrObjectNotFound))
+					continue
+				}
+
+				err := w.br.del(b, q.key)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err := w.br.put(b, q.key, q.data)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, err := range errs {
+		w.onError(err)
+	}
+
+	return nil
+}
+
+// Close stops background flushing and flushes the remaining writes.
+// Writes queued after Close return ErrWriteBehindClosed
+func (w *WriteBehind) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	w.wg.Wait()
+
+	return w.Flush()
+}
+
+func (w *WriteBehind) run() {
+	defer w.wg.Done()
+
+	var tick <-chan time.Time
+	if w.cfg.Interval > 0 {
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-tick:
+		case <-w.kick:
+		}
+
+		err := w.Flush()
+		if err != nil {
+			w.onError(err)
+		}
+	}
+}
+
+func (w *WriteBehind) onError(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
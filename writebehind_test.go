@@ -0,0 +1,133 @@
+package bbucket
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"git.fuyu.moe/Fuyu/assert"
+)
+
+func TestWriteBehind(t *testing.T) {
+	t.Run(`flush`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		var errs []error
+		w := NewWriteBehind(br, WriteBehindConfig{OnError: func(err error) {
+			errs = append(errs, err)
+		}})
+
+		obj := testStruct4
+		assert.NoError(w.Create(obj.Key(), obj))
+		obj.Data = 1 // encoded on Create
+		assert.NoError(w.Create(testStruct1.Key(), testStruct5))
+		assert.NoError(w.Put(testStruct2.Key(), testStruct{`BCD`, 1}))
+		assert.NoError(w.Delete(testStruct3.Key()))
+		assert.NoError(w.Delete([]byte(`blablabla`)))
+
+		// nothing is stored before flushing
+		assertUnchanged(assert, br)
+
+		assert.NoError(w.Flush())
+		assert.Cmp(map[string]testStruct{
+			`ABC`: testStruct1,
+			`BCD`: {`BCD`, 1},
+			`XYZ`: testStruct4,
+		}, getAllByKey(br))
+
+		assert.Eq(2, len(errs))
+		assert.Eq(true, errors.Is(errs[0], ErrObjectAlreadyExists))
+		assert.Eq(true, errors.Is(errs[1], ErrObjectNotFound))
+
+		assert.NoError(w.Close())
+		assert.Eq(ErrWriteBehindClosed, w.Put(testStruct1.Key(), testStruct1))
+		assert.NoError(w.Close())
+	})
+
+	t.Run(`order per key`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		w := NewWriteBehind(br, WriteBehindConfig{MaxOps: 3})
+		for i := 0; i < 100; i++ {
+			assert.NoError(w.Put(testStruct1.Key(), testStruct{`ABC`, i}))
+		}
+		assert.NoError(w.Delete(testStruct2.Key()))
+		assert.NoError(w.Create(testStruct2.Key(), testStruct{`BCD`, 1}))
+		assert.NoError(w.Close())
+
+		assert.Cmp(map[string]testStruct{
+			`ABC`: {`ABC`, 99},
+			`BCD`: {`BCD`, 1},
+			`CDE`: testStruct3,
+		}, getAllByKey(br))
+	})
+
+	t.Run(`background flush`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		w := NewWriteBehind(br, WriteBehindConfig{Interval: time.Millisecond})
+		defer w.Close()
+
+		assert.NoError(w.Create(testStruct4.Key(), testStruct4))
+
+		var err error
+		for i := 0; i < 1000; i++ {
+			_, err = getTestStruct(br, testStruct4.Key())
+			if err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		assert.NoError(err)
+	})
+
+	t.Run(`max ops`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		var mu sync.Mutex
+		var errs []error
+		w := NewWriteBehind(br, WriteBehindConfig{MaxOps: 2, OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}})
+		defer w.Close()
+
+		assert.NoError(w.Create(testStruct1.Key(), testStruct1))
+		assert.NoError(w.Create(testStruct4.Key(), testStruct4))
+
+		for i := 0; i < 1000; i++ {
+			mu.Lock()
+			n := len(errs)
+			mu.Unlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Eq(1, len(errs))
+	})
+
+	t.Run(`marshal error`, func(t *testing.T) {
+		assert := assert.New(t)
+		br := getTestRepo()
+		defer br.Close()
+
+		w := NewWriteBehind(br, WriteBehindConfig{})
+		defer w.Close()
+
+		assert.Error(w.Put(testStruct1.Key(), make(chan int)))
+	})
+}